@@ -0,0 +1,127 @@
+package main
+
+import (
+	"time"
+
+	"go-intepreter/gen"
+)
+
+// Callable is a value that can be called: a function declared in the program
+// or one built into the interpreter.
+type Callable interface {
+	// Arity is the number of arguments the callable takes.
+	Arity() int
+	// Call returns the result of the call. If the call fails, failure is
+	// the error, which is thrown from the call.
+	Call(interpreter *Interpreter, arguments []interface{}) (result interface{}, failure *RuntimeError)
+}
+
+// Function is a function declared in the program. It keeps the environment
+// it was declared in, so the body can use the variables around the
+// declaration even after that scope has finished (a closure).
+type Function struct {
+	declaration *gen.Function
+	closure     *Environment
+
+	// isInitializer marks a class's init method, which always returns the
+	// instance it initialized.
+	isInitializer bool
+}
+
+func NewFunction(declaration *gen.Function, closure *Environment, isInitializer bool) *Function {
+	return &Function{declaration: declaration, closure: closure, isInitializer: isInitializer}
+}
+
+// bind returns the method f with 'this' defined as instance.
+func (f *Function) bind(instance *Instance) *Function {
+	environment := NewEnvironment(f.closure)
+	environment.Define("this", instance)
+	return NewFunction(f.declaration, environment, f.isInitializer)
+}
+
+func (f *Function) Arity() int {
+	return len(f.declaration.Params)
+}
+
+// maxCallDepth is how deep calls can nest before the program is assumed to
+// be recursing forever.
+const maxCallDepth = 10000
+
+// Call runs the body in a new scope holding the parameters. Each call gets
+// its own scope, so recursive calls do not share variables.
+func (f *Function) Call(interpreter *Interpreter, arguments []interface{}) (result interface{}, failure *RuntimeError) {
+	if interpreter.depth >= maxCallDepth {
+		return nil, NewRuntimeError(RecursionError, "Stack overflow.")
+	}
+	interpreter.depth++
+	defer func() { interpreter.depth-- }()
+
+	environment := NewEnvironment(f.closure)
+	for i, param := range f.declaration.Params {
+		environment.Define(param.Lexeme, arguments[i])
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			value, ok := r.(*returnValue)
+			if !ok {
+				panic(r)
+			}
+			result = value.value
+		}
+		if f.isInitializer {
+			// bind put 'this' in the closure's only slot.
+			result = f.closure.GetAt(0, 0)
+		}
+	}()
+	interpreter.executeBlock(f.declaration.Body, environment)
+	return nil, nil
+}
+
+func (f *Function) String() string {
+	return "<fn " + f.declaration.Name.Lexeme + ">"
+}
+
+// returnValue carries the value of a return statement out of the function
+// body; the return statement panics with it and Call recovers it.
+type returnValue struct {
+	value interface{}
+}
+
+// nativeFunction is a function implemented in Go.
+type nativeFunction struct {
+	arity int
+	fn    func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError)
+}
+
+func (n *nativeFunction) Arity() int {
+	return n.arity
+}
+
+func (n *nativeFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+	return n.fn(interpreter, arguments)
+}
+
+func (n *nativeFunction) String() string {
+	return "<native fn>"
+}
+
+// defineNatives adds the built-in functions to the global scope.
+func defineNatives(globals *Environment) {
+	// clock returns the seconds since the Unix epoch, for timing scripts.
+	globals.Define("clock", &nativeFunction{
+		arity: 0,
+		fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			return float64(time.Now().UnixNano()) / float64(time.Second), nil
+		},
+	})
+
+	// Error returns a new error value with message as its message, for
+	// programs to throw their own errors.
+	globals.Define("Error", &nativeFunction{
+		arity: 1,
+		fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			return NewRuntimeError(UserError, interpreter.stringify(arguments[0])), nil
+		},
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+// Class is a class declared in the program. Calling it creates an instance
+// and runs its init method, if it has one, with the call's arguments.
+type Class struct {
+	name       string
+	superclass *Class
+	methods    map[string]*Function
+}
+
+func NewClass(name string, superclass *Class, methods map[string]*Function) *Class {
+	return &Class{name: name, superclass: superclass, methods: methods}
+}
+
+// findMethod looks name up in the class and then up its superclasses.
+func (c *Class) findMethod(name string) *Function {
+	for class := c; class != nil; class = class.superclass {
+		if method, ok := class.methods[name]; ok {
+			return method
+		}
+	}
+	return nil
+}
+
+// isSubclassOf reports whether c is other or inherits from it.
+func (c *Class) isSubclassOf(other *Class) bool {
+	for class := c; class != nil; class = class.superclass {
+		if class == other {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Class) Arity() int {
+	if initializer := c.findMethod("init"); initializer != nil {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (c *Class) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+	instance := NewInstance(c)
+	if initializer := c.findMethod("init"); initializer != nil {
+		if _, failure := initializer.bind(instance).Call(interpreter, arguments); failure != nil {
+			return nil, failure
+		}
+	}
+	return instance, nil
+}
+
+func (c *Class) String() string {
+	return c.name
+}
+
+// Instance is an object created by calling a class. Fields are created by
+// assigning to them and shadow methods of the same name.
+type Instance struct {
+	class  *Class
+	fields map[string]interface{}
+}
+
+func NewInstance(class *Class) *Instance {
+	return &Instance{class: class, fields: make(map[string]interface{})}
+}
+
+// Get returns the field called name, or else the method called name bound to
+// this instance.
+func (i *Instance) Get(name string) (interface{}, bool) {
+	if value, ok := i.fields[name]; ok {
+		return value, true
+	}
+	if method := i.class.findMethod(name); method != nil {
+		return method.bind(i), true
+	}
+	return nil, false
+}
+
+func (i *Instance) Set(name string, value interface{}) {
+	i.fields[name] = value
+}
+
+func (i *Instance) String() string {
+	return i.class.name + " instance"
+}
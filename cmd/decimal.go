@@ -0,0 +1,200 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+
+	"go-intepreter/tokens"
+)
+
+// Decimal is a fixed-point number, unscaled / 10^scale. Decimal literals are
+// written with a d suffix (12.50d) and keep the scale they were written with,
+// so 12.50d prints as 12.50.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+var bigTen = big.NewInt(10)
+
+// pow10 returns 10^n for n >= 0.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// parseDecimal parses the digits of a decimal literal: an integer part, an
+// optional fraction and an optional exponent, without underscores.
+func parseDecimal(text string) (*Decimal, bool) {
+	mantissa, exponent := text, 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		mantissa = text[:i]
+		e, ok := new(big.Int).SetString(text[i+1:], 10)
+		if !ok || !e.IsInt64() || e.Int64() > 1<<20 || e.Int64() < -(1<<20) {
+			return nil, false
+		}
+		exponent = int(e.Int64())
+	}
+
+	scale := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = len(mantissa) - i - 1
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+	unscaled, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return nil, false
+	}
+
+	scale -= exponent
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return &Decimal{unscaled: unscaled, scale: scale}, true
+}
+
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.unscaled).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if d.unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// rescaled returns the unscaled value of d at a scale no smaller than its own.
+func (d *Decimal) rescaled(scale int) *big.Int {
+	if scale == d.scale {
+		return d.unscaled
+	}
+	return new(big.Int).Mul(d.unscaled, pow10(scale-d.scale))
+}
+
+// Rat returns the exact value of d.
+func (d *Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+func (d *Decimal) Cmp(other *Decimal) int {
+	scale := max(d.scale, other.scale)
+	return d.rescaled(scale).Cmp(other.rescaled(scale))
+}
+
+func (d *Decimal) Neg() *Decimal {
+	return &Decimal{unscaled: new(big.Int).Neg(d.unscaled), scale: d.scale}
+}
+
+// trimmed drops trailing fractional zeros, keeping at least minScale digits.
+func (d *Decimal) trimmed(minScale int) *Decimal {
+	unscaled, scale := new(big.Int).Set(d.unscaled), d.scale
+	remainder := new(big.Int)
+	for scale > minScale {
+		quotient, _ := new(big.Int).QuoRem(unscaled, bigTen, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+		unscaled, scale = quotient, scale-1
+	}
+	return &Decimal{unscaled: unscaled, scale: scale}
+}
+
+func toDecimal(value interface{}) *Decimal {
+	switch v := value.(type) {
+	case int64:
+		return &Decimal{unscaled: big.NewInt(v)}
+	case *big.Int:
+		return &Decimal{unscaled: v}
+	case *Decimal:
+		return v
+	}
+	return nil
+}
+
+// roundingMode decides which way a result with too many fractional digits
+// is rounded to the decimal scale.
+type roundingMode string
+
+const (
+	roundHalfEven roundingMode = "half-even" // to nearest, ties to even (banker's rounding)
+	roundHalfUp   roundingMode = "half-up"   // to nearest, ties away from zero
+	roundHalfDown roundingMode = "half-down" // to nearest, ties toward zero
+	roundUp       roundingMode = "up"        // away from zero
+	roundDown     roundingMode = "down"      // toward zero
+	roundCeiling  roundingMode = "ceiling"   // toward positive infinity
+	roundFloor    roundingMode = "floor"     // toward negative infinity
+)
+
+var roundingModes = []roundingMode{roundHalfEven, roundHalfUp, roundHalfDown, roundUp, roundDown, roundCeiling, roundFloor}
+
+// round returns r rounded to scale fractional digits.
+func (mode roundingMode) round(r *big.Rat, scale int) *Decimal {
+	numerator := new(big.Int).Mul(r.Num(), pow10(scale))
+	denominator := r.Denom()
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() == 0 {
+		return &Decimal{unscaled: quotient, scale: scale}
+	}
+
+	// quotient was truncated toward zero; decide whether to step away from it.
+	sign := r.Sign()
+	half := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(denominator)
+	away := false
+	switch mode {
+	case roundHalfEven:
+		away = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+	case roundHalfUp:
+		away = half >= 0
+	case roundHalfDown:
+		away = half > 0
+	case roundUp:
+		away = true
+	case roundDown:
+		away = false
+	case roundCeiling:
+		away = sign > 0
+	case roundFloor:
+		away = sign < 0
+	}
+	if away {
+		quotient.Add(quotient, big.NewInt(int64(sign)))
+	}
+	return &Decimal{unscaled: quotient, scale: scale}
+}
+
+// decimalArithmetic applies one of + - * / % to two decimals. Addition,
+// subtraction and remainder are exact. Products and quotients are rounded
+// with the rounding mode to the decimal scale, but never to fewer fractional
+// digits than either operand has. Quotients then drop trailing zeros beyond
+// that, so 10.00d / 4 is 2.50 rather than 2.5000000000000000.
+func (m numberMode) decimalArithmetic(op tokens.TokenType, l, r *Decimal) (interface{}, string) {
+	scale := max(l.scale, r.scale)
+	switch op {
+	case tokens.PLUS:
+		return &Decimal{unscaled: new(big.Int).Add(l.rescaled(scale), r.rescaled(scale)), scale: scale}, ""
+	case tokens.MINUS:
+		return &Decimal{unscaled: new(big.Int).Sub(l.rescaled(scale), r.rescaled(scale)), scale: scale}, ""
+	case tokens.STAR:
+		product := &Decimal{unscaled: new(big.Int).Mul(l.unscaled, r.unscaled), scale: l.scale + r.scale}
+		if target := max(m.decimalScale, scale); product.scale > target {
+			return m.rounding.round(product.Rat(), target), ""
+		}
+		return product, ""
+	case tokens.SLASH:
+		if r.unscaled.Sign() == 0 {
+			return nil, "Division by zero."
+		}
+		quotient := new(big.Rat).Quo(l.Rat(), r.Rat())
+		return m.rounding.round(quotient, max(m.decimalScale, scale)).trimmed(scale), ""
+	case tokens.PERCENT:
+		if r.unscaled.Sign() == 0 {
+			return nil, "Division by zero."
+		}
+		return &Decimal{unscaled: new(big.Int).Rem(l.rescaled(scale), r.rescaled(scale)), scale: scale}, ""
+	}
+	return nil, "Unknown arithmetic operator."
+}
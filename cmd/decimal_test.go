@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		value string
+		scale int
+		want  map[roundingMode]string
+	}{
+		{"5/2", 0, map[roundingMode]string{
+			roundHalfEven: "2", roundHalfUp: "3", roundHalfDown: "2",
+			roundUp: "3", roundDown: "2", roundCeiling: "3", roundFloor: "2",
+		}},
+		{"-5/2", 0, map[roundingMode]string{
+			roundHalfEven: "-2", roundHalfUp: "-3", roundHalfDown: "-2",
+			roundUp: "-3", roundDown: "-2", roundCeiling: "-2", roundFloor: "-3",
+		}},
+		{"7/2", 0, map[roundingMode]string{
+			roundHalfEven: "4", roundHalfUp: "4", roundHalfDown: "3",
+			roundUp: "4", roundDown: "3", roundCeiling: "4", roundFloor: "3",
+		}},
+		{"1/3", 2, map[roundingMode]string{
+			roundHalfEven: "0.33", roundHalfUp: "0.33", roundHalfDown: "0.33",
+			roundUp: "0.34", roundDown: "0.33", roundCeiling: "0.34", roundFloor: "0.33",
+		}},
+		{"-2/3", 2, map[roundingMode]string{
+			roundHalfEven: "-0.67", roundHalfUp: "-0.67", roundHalfDown: "-0.67",
+			roundUp: "-0.67", roundDown: "-0.66", roundCeiling: "-0.66", roundFloor: "-0.67",
+		}},
+		{"1/200", 2, map[roundingMode]string{
+			roundHalfEven: "0.00", roundHalfUp: "0.01", roundHalfDown: "0.00",
+			roundUp: "0.01", roundDown: "0.00", roundCeiling: "0.01", roundFloor: "0.00",
+		}},
+		{"-1/200", 2, map[roundingMode]string{
+			roundHalfEven: "0.00", roundHalfUp: "-0.01", roundHalfDown: "0.00",
+			roundUp: "-0.01", roundDown: "0.00", roundCeiling: "0.00", roundFloor: "-0.01",
+		}},
+		// Exact values are never changed, whatever the mode.
+		{"3/2", 1, map[roundingMode]string{
+			roundHalfEven: "1.5", roundHalfUp: "1.5", roundHalfDown: "1.5",
+			roundUp: "1.5", roundDown: "1.5", roundCeiling: "1.5", roundFloor: "1.5",
+		}},
+		{"12", 2, map[roundingMode]string{
+			roundHalfEven: "12.00", roundHalfUp: "12.00", roundHalfDown: "12.00",
+			roundUp: "12.00", roundDown: "12.00", roundCeiling: "12.00", roundFloor: "12.00",
+		}},
+	}
+	for _, test := range tests {
+		value, ok := new(big.Rat).SetString(test.value)
+		if !ok {
+			t.Fatalf("bad test value %q", test.value)
+		}
+		for _, mode := range roundingModes {
+			want, ok := test.want[mode]
+			if !ok {
+				t.Errorf("%s at scale %d: no expectation for %s", test.value, test.scale, mode)
+				continue
+			}
+			if got := mode.round(value, test.scale).String(); got != want {
+				t.Errorf("%s rounded %s to scale %d = %s, want %s", test.value, mode, test.scale, got, want)
+			}
+		}
+	}
+}
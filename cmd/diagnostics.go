@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"go-intepreter/tokens"
+)
+
+// source is the scanner reading the program. The scanner streams the source,
+// so diagnostics ask it for the lines they quote rather than reading the
+// source again, which standard input would not allow.
+var source *Scanner
+
+// quoteSource writes the snippet for span if the scanner still has its line.
+func quoteSource(w io.Writer, span tokens.Span) {
+	if source == nil || span.Line == 0 {
+		return
+	}
+	if line, lineBegin, ok := source.SourceLine(span.Line); ok {
+		renderSnippet(w, line, lineBegin, span)
+	}
+}
+
+// renderSnippet writes line, the source line a span starts on beginning at
+// offset lineBegin, followed by a line of carets under the span:
+//
+//	1 | (3 + ) * 2
+//	  |      ^
+//
+// Spans running past the end of the line are underlined up to the line end.
+// An empty span (such as the one for EOF) still gets a single caret.
+func renderSnippet(w io.Writer, line string, lineBegin int, span tokens.Span) {
+	line = strings.TrimRight(line, "\r")
+	if span.Start < lineBegin || span.Start > lineBegin+len(line) {
+		return
+	}
+
+	end := span.End
+	if end > lineBegin+len(line) {
+		end = lineBegin + len(line)
+	}
+
+	// Columns count runes, so pad and underline one cell per rune. Tabs are
+	// kept in the padding so the carets line up with the quoted source.
+	var pad strings.Builder
+	for _, c := range line[:span.Start-lineBegin] {
+		if c == '\t' {
+			pad.WriteByte('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+	width := 0
+	if end > span.Start {
+		width = utf8.RuneCountInString(line[span.Start-lineBegin : end-lineBegin])
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	gutter := fmt.Sprintf("%4d", span.Line)
+	fmt.Fprintf(w, "%s | %s\n", gutter, line)
+	fmt.Fprintf(w, "%s | %s%s\n", strings.Repeat(" ", len(gutter)), pad.String(), strings.Repeat("^", width))
+}
+
+// DiagnosticKind classifies a problem found in the source.
+type DiagnosticKind string
+
+const (
+	UnexpectedCharacter DiagnosticKind = "UnexpectedCharacter"
+	UnterminatedString  DiagnosticKind = "UnterminatedString"
+	InvalidEncoding     DiagnosticKind = "InvalidEncoding"
+	InvalidEscape       DiagnosticKind = "InvalidEscape"
+	UnterminatedComment DiagnosticKind = "UnterminatedComment"
+	ReadFailure         DiagnosticKind = "ReadFailure"
+	InvalidNumber       DiagnosticKind = "InvalidNumber"
+)
+
+// Diagnostic is a problem found while scanning, tied to the region of the
+// source it concerns. Producing one does not stop the scanner; callers decide
+// whether the problems are fatal.
+type Diagnostic struct {
+	Kind    DiagnosticKind
+	Message string
+	Span    tokens.Span
+}
+
+// reportDiagnostics prints every diagnostic in order and records that an
+// error occurred if there were any.
+func reportDiagnostics(diagnostics []Diagnostic) {
+	for _, d := range diagnostics {
+		error(d.Span, d.Message, "")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+// Environment holds the variables of one scope. Blocks get an environment of
+// their own whose enclosing environment is the scope around them.
+//
+// Globals are kept by name, since a global may be used before it is declared
+// and may be declared again, which replaces it. A local scope keeps its
+// variables in slots, in the order they are declared; the resolver gives every
+// local reference the distance and slot of its variable, so reading one never
+// looks at names.
+type Environment struct {
+	// values holds the variables of the global scope. It is nil in a local
+	// scope.
+	values map[string]interface{}
+	slots  []interface{}
+
+	enclosing *Environment
+}
+
+func NewEnvironment(enclosing *Environment) *Environment {
+	if enclosing == nil {
+		return &Environment{values: make(map[string]interface{})}
+	}
+	return &Environment{enclosing: enclosing}
+}
+
+// Define declares name in this scope. In a local scope the variable takes the
+// next slot, so variables must be defined in the order the resolver declared
+// them; name is only used by the global scope.
+func (e *Environment) Define(name string, value interface{}) {
+	if e.values != nil {
+		e.values[name] = value
+		return
+	}
+	e.slots = append(e.slots, value)
+}
+
+// Get returns the value of the global called name. It is called on the global
+// scope.
+func (e *Environment) Get(name string) (interface{}, bool) {
+	value, ok := e.values[name]
+	return value, ok
+}
+
+// Assign sets the global called name. It returns false if it is not declared;
+// assignment never creates a variable.
+func (e *Environment) Assign(name string, value interface{}) bool {
+	if _, ok := e.values[name]; !ok {
+		return false
+	}
+	e.values[name] = value
+	return true
+}
+
+// ancestor returns the environment distance scopes out from e.
+func (e *Environment) ancestor(distance int) *Environment {
+	env := e
+	for n := 0; n < distance; n++ {
+		env = env.enclosing
+	}
+	return env
+}
+
+// GetAt returns the variable in the given slot of the scope distance steps
+// out, where the resolver found it.
+func (e *Environment) GetAt(distance, slot int) interface{} {
+	return e.ancestor(distance).slots[slot]
+}
+
+// AssignAt sets the variable in the given slot of the scope distance steps
+// out.
+func (e *Environment) AssignAt(distance, slot int, value interface{}) {
+	e.ancestor(distance).slots[slot] = value
+}
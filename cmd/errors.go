@@ -0,0 +1,135 @@
+package main
+
+import (
+	"go-intepreter/gen"
+	"go-intepreter/tokens"
+)
+
+// ErrorKind classifies a runtime error, so that a catch clause can tell a
+// missing key from a bad operand.
+type ErrorKind string
+
+const (
+	// UserError is the kind of errors made by calling Error in the program.
+	UserError       ErrorKind = "Error"
+	TypeError       ErrorKind = "TypeError"
+	NameError       ErrorKind = "NameError"
+	ArithmeticError ErrorKind = "ArithmeticError"
+	IndexError      ErrorKind = "IndexError"
+	KeyError        ErrorKind = "KeyError"
+	PropertyError   ErrorKind = "PropertyError"
+	MatchError      ErrorKind = "MatchError"
+	RecursionError  ErrorKind = "RecursionError"
+)
+
+// RuntimeError is an error value. The interpreter throws one whenever the
+// program does something it can't, and the program can make its own with
+// Error(message). Its properties are message, kind, line and column.
+type RuntimeError struct {
+	Kind    ErrorKind
+	Message string
+	// Span is where the error happened. It is the zero Span until the error
+	// is thrown if it was made without one.
+	Span tokens.Span
+}
+
+func NewRuntimeError(kind ErrorKind, message string) *RuntimeError {
+	return &RuntimeError{Kind: kind, Message: message}
+}
+
+// located reports whether the error has been given a place in the source.
+func (e *RuntimeError) located() bool {
+	return e.Span.Line != 0
+}
+
+func (e *RuntimeError) Get(name string) (interface{}, bool) {
+	switch name {
+	case "message":
+		return e.Message, true
+	case "kind":
+		return string(e.Kind), true
+	case "line":
+		return int64(e.Span.Line), true
+	case "column":
+		return int64(e.Span.Column), true
+	}
+	return nil, false
+}
+
+func (e *RuntimeError) String() string {
+	return string(e.Kind) + ": " + e.Message
+}
+
+// thrownValue carries a thrown value up to the nearest enclosing try; the
+// throw panics with it and VisitTryStmt recovers it. Any value can be thrown,
+// so span records where the throw happened for reporting it if nothing
+// catches it.
+type thrownValue struct {
+	value interface{}
+	span  tokens.Span
+}
+
+// throw throws value from span. An error without a place in the source gets
+// span as its place.
+func (i *Interpreter) throw(value interface{}, span tokens.Span) {
+	if err, ok := value.(*RuntimeError); ok && !err.located() {
+		err.Span = span
+	}
+	panic(&thrownValue{value: value, span: span})
+}
+
+// catch runs body and returns what it threw, or nil if it finished normally.
+// Anything else body panics with, such as a return, is passed on.
+func (i *Interpreter) catch(body func()) (caught *thrownValue) {
+	defer func() {
+		if r := recover(); r != nil {
+			thrown, ok := r.(*thrownValue)
+			if !ok {
+				panic(r)
+			}
+			caught = thrown
+		}
+	}()
+	body()
+	return nil
+}
+
+// VisitThrowStmt throws the value of the statement's expression.
+func (i *Interpreter) VisitThrowStmt(stmt *gen.Throw) interface{} {
+	i.throw(i.Evaluate(stmt.Value), stmt.Keyword.Span())
+	return nil
+}
+
+// VisitTryStmt runs the try block. If it throws, the catch block runs with
+// the thrown value bound to the catch clause's name; if there is no catch
+// clause the value carries on being thrown. The finally block runs last
+// however the others finish, including by returning or throwing.
+func (i *Interpreter) VisitTryStmt(stmt *gen.Try) interface{} {
+	if stmt.FinallyBody != nil {
+		defer i.executeBlock(stmt.FinallyBody, NewEnvironment(i.environment))
+	}
+	if stmt.CatchBody == nil {
+		i.executeBlock(stmt.Body, NewEnvironment(i.environment))
+		return nil
+	}
+	caught := i.catch(func() {
+		i.executeBlock(stmt.Body, NewEnvironment(i.environment))
+	})
+	if caught != nil {
+		environment := NewEnvironment(i.environment)
+		if stmt.CatchName != nil {
+			environment.Define(stmt.CatchName.Lexeme, caught.value)
+		}
+		i.executeBlock(stmt.CatchBody, environment)
+	}
+	return nil
+}
+
+// reportUncaught reports a value thrown out of the whole program.
+func (i *Interpreter) reportUncaught(thrown *thrownValue) {
+	if err, ok := thrown.value.(*RuntimeError); ok {
+		report(err.Span, "", err.Message, "")
+		return
+	}
+	report(thrown.span, "", "Uncaught exception:", i.stringify(thrown.value))
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// prelude is the first line of the programs in TestRuntimeErrorKinds. The
+// code under test goes on line 2, inside the try it opens.
+const prelude = `class C {} var m = {"a": 1}; fun rec() { return rec(); } var caught; try {`
+
+// TestRuntimeErrorKinds checks that each kind of runtime failure can be
+// caught and reports its kind and where it happened.
+func TestRuntimeErrorKinds(t *testing.T) {
+	// The recursion fails at the innermost call, in rec's body.
+	recursionColumn := strings.Index(prelude, "rec(); }") + len("rec()")
+
+	tests := []struct {
+		kind         ErrorKind
+		code         string
+		line, column int
+		message      string
+	}{
+		{TypeError, `print "a" - 1;`, 2, 7, "Operands must be numbers."},
+		{TypeError, `print "a" + 1;`, 2, 7, "Operands must be two numbers or two strings."},
+		{TypeError, `print 1 < "b";`, 2, 7, "Operands must be numbers."},
+		{TypeError, `print -"a";`, 2, 7, "Operand must be a number."},
+		{TypeError, `print 1.5 + 1.5d;`, 2, 7, "Cannot mix decimal and float operands."},
+		{TypeError, `"s"();`, 2, 5, "Can only call functions and classes."},
+		{TypeError, `clock(1);`, 2, 8, "Expected 0 arguments but got 1."},
+		{TypeError, `print (1).x;`, 2, 11, "Only instances, lists, maps and errors have properties."},
+		{TypeError, `print [1]["a"];`, 2, 14, "List index must be an integer."},
+		{TypeError, `print m.has([]);`, 2, 15, "Only numbers, strings, booleans and nil can be map keys."},
+		{NameError, `print nope;`, 2, 7, "Undefined variable 'nope'."},
+		{NameError, `nope = 1;`, 2, 1, "Undefined variable 'nope'."},
+		{ArithmeticError, `print 1 / 0;`, 2, 7, "Division by zero."},
+		{ArithmeticError, `print 5 % 0;`, 2, 7, "Division by zero."},
+		{ArithmeticError, `print 1.5d / 0;`, 2, 7, "Division by zero."},
+		{IndexError, `print [1][5];`, 2, 12, "List index 5 out of range for length 1."},
+		{IndexError, `print [1][-2];`, 2, 13, "List index -2 out of range for length 1."},
+		{IndexError, `[].pop();`, 2, 8, "Can't pop from an empty list."},
+		{KeyError, `print m["b"];`, 2, 12, `Key "b" not found.`},
+		{PropertyError, `print C().x;`, 2, 11, "Undefined property 'x'."},
+		{MatchError, `print match (1) { 2 => 2, 3 => 3 };`, 2, 7, "No match arm matches 1."},
+		{RecursionError, `rec();`, 1, recursionColumn, "Stack overflow."},
+		{UserError, `throw Error("mine");`, 2, 1, "mine"},
+	}
+	for _, test := range tests {
+		t.Run(string(test.kind)+" "+test.code, func(t *testing.T) {
+			interpreter := run(t, prelude+"\n"+test.code+"\n} catch (e) { caught = e; }")
+			caught, _ := interpreter.globals.Get("caught")
+			err, ok := caught.(*RuntimeError)
+			if !ok {
+				t.Fatalf("caught %s, want an error", interpreter.stringify(caught))
+			}
+			if err.Kind != test.kind || err.Message != test.message {
+				t.Errorf("caught %s, want %s: %s", err, test.kind, test.message)
+			}
+			if err.Span.Line != test.line || err.Span.Column != test.column {
+				t.Errorf("error at %d:%d, want %d:%d", err.Span.Line, err.Span.Column, test.line, test.column)
+			}
+		})
+	}
+}
+
+// TestErrorProperties checks the properties a program can read from an
+// error.
+func TestErrorProperties(t *testing.T) {
+	interpreter := run(t, `
+		var e;
+		try { 1 / 0; } catch (caught) { e = caught; }
+		var message = e.message;
+		var kind = e.kind;
+		var line = e.line;
+		var column = e.column;
+		var text = "${e}";
+	`)
+	checkGlobals(t, interpreter, map[string]string{
+		"message": "Division by zero.",
+		"kind":    "ArithmeticError",
+		"line":    "3",
+		"column":  "9",
+		"text":    "ArithmeticError: Division by zero.",
+	})
+}
+
+func TestTryCatchFinally(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   map[string]string
+	}{
+		{"catch runs only when the try throws", `
+			var log = [];
+			try { log.push("try"); } catch (e) { log.push("catch"); }
+			try { throw 1; log.push("unreached"); } catch (e) { log.push(e); }
+		`, map[string]string{"log": `["try", 1]`}},
+
+		{"any value can be thrown", `
+			var values = [];
+			class Problem { init(code) { this.code = code; } }
+			try { throw "text"; } catch (e) { values.push(e); }
+			try { throw [1, 2]; } catch (e) { values.push(e); }
+			try { throw nil; } catch (e) { values.push(e); }
+			var code;
+			try { throw Problem(7); } catch (e) { code = e.code; }
+		`, map[string]string{"values": `["text", [1, 2], nil]`, "code": "7"}},
+
+		{"catch without a name", `
+			var caught = false;
+			try { [].pop(); } catch { caught = true; }
+		`, map[string]string{"caught": "true"}},
+
+		{"finally runs however the try ends", `
+			var log = [];
+			try { log.push("try"); } finally { log.push("finally"); }
+			try { throw 1; } catch (e) { log.push("catch"); } finally { log.push("finally"); }
+		`, map[string]string{"log": `["try", "finally", "catch", "finally"]`}},
+
+		{"finally runs on return", `
+			var log = [];
+			fun f() {
+				try { return "try"; } finally { log.push("finally"); }
+				return "unreached";
+			}
+			fun g() {
+				try { throw 1; } catch (e) { return "catch"; } finally { log.push("finally"); }
+			}
+			var fromTry = f();
+			var fromCatch = g();
+		`, map[string]string{"log": `["finally", "finally"]`, "fromTry": "try", "fromCatch": "catch"}},
+
+		{"finally runs when the error carries on", `
+			var log = [];
+			try {
+				try { throw "inner"; } finally { log.push("finally"); }
+			} catch (e) {
+				log.push("outer " + e);
+			}
+		`, map[string]string{"log": `["finally", "outer inner"]`}},
+
+		{"finally runs on a rethrow", `
+			var log = [];
+			try {
+				try { throw "first"; } catch (e) { throw e + " again"; } finally { log.push("finally"); }
+			} catch (e) {
+				log.push(e);
+			}
+		`, map[string]string{"log": `["finally", "first again"]`}},
+
+		{"a throw from a catch reaches the outer try", `
+			var outer;
+			try {
+				try { 1 / 0; } catch (e) { nope; }
+			} catch (e) {
+				outer = e.kind;
+			}
+		`, map[string]string{"outer": "NameError"}},
+
+		{"a throw from a finally replaces the error", `
+			var caught;
+			try {
+				try { throw "first"; } finally { throw "second"; }
+			} catch (e) {
+				caught = e;
+			}
+		`, map[string]string{"caught": "second"}},
+
+		{"errors unwind through calls", `
+			fun parse(record) { return 100 / record; }
+			var results = [];
+			var failures = 0;
+			for (var i = 0; i < 4; i++) {
+				try { results.push(parse([4, 0, "x", 5][i])); } catch (e) { failures++; }
+			}
+		`, map[string]string{"results": "[25, 20]", "failures": "2"}},
+
+		{"the catch name is scoped to the catch block", `
+			var e = "outer";
+			var inside;
+			try { throw "thrown"; } catch (e) { inside = e; }
+			var after = e;
+			fun f() {
+				var e = "local";
+				try { throw "thrown"; } catch (e) { }
+				return e;
+			}
+			var local = f();
+		`, map[string]string{"inside": "thrown", "after": "outer", "local": "local"}},
+
+		{"scopes are restored after a throw", `
+			var x = "global";
+			fun f() {
+				var x = "function";
+				try {
+					var x = "try";
+					{ var x = "block"; throw 1; }
+				} catch (e) { }
+				return x;
+			}
+			var seen = f();
+		`, map[string]string{"seen": "function"}},
+
+		{"the call depth recovers after a stack overflow", `
+			fun down(n) { return down(n + 1); }
+			fun count(n) { if (n == 0) return 0; return 1 + count(n - 1); }
+			var first; var second;
+			try { down(0); } catch (e) { first = e.kind; }
+			try { down(0); } catch (e) { second = e.kind; }
+			var deep = count(5000);
+		`, map[string]string{"first": "RecursionError", "second": "RecursionError", "deep": "5000"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkGlobals(t, run(t, test.source), test.want)
+		})
+	}
+}
+
+// TestCatchNameNotVisibleAfter checks that the resolver does not let the
+// catch clause's name leak out of the catch block.
+func TestCatchNameNotVisibleAfter(t *testing.T) {
+	statements, _ := NewParser(NewScanner(`
+		fun f() {
+			try { throw 1; } catch (problem) { }
+			return problem;
+		}
+		var result;
+		try { f(); } catch (e) { result = e.kind; }
+	`)).parse()
+	interpreter := NewInterpreter()
+	NewResolver(interpreter).resolve(statements)
+	for _, stmt := range statements {
+		interpreter.execute(stmt)
+	}
+	checkGlobals(t, interpreter, map[string]string{"result": "NameError"})
+}
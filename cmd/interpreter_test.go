@@ -0,0 +1,126 @@
+package main
+
+import "testing"
+
+// run parses, resolves and runs the program source, failing the test if
+// anything is thrown out of it. The program's globals are left in the
+// returned interpreter.
+func run(t *testing.T, source string) *Interpreter {
+	t.Helper()
+	statements, parseErrors := NewParser(NewScanner(source)).parse()
+	if len(parseErrors) > 0 {
+		t.Fatalf("program does not parse: %v", parseErrors[0])
+	}
+	interpreter := NewInterpreter()
+	NewResolver(interpreter).resolve(statements)
+	thrown := interpreter.catch(func() {
+		for _, stmt := range statements {
+			interpreter.execute(stmt)
+		}
+	})
+	if thrown != nil {
+		t.Fatalf("program threw %s", interpreter.stringify(thrown.value))
+	}
+	return interpreter
+}
+
+// checkGlobals checks that each global in want has the value that prints as
+// the string it maps to.
+func checkGlobals(t *testing.T, interpreter *Interpreter, want map[string]string) {
+	t.Helper()
+	for name, value := range want {
+		got, ok := interpreter.globals.Get(name)
+		if !ok {
+			t.Errorf("%s is not defined", name)
+			continue
+		}
+		if s := interpreter.stringify(got); s != value {
+			t.Errorf("%s = %s, want %s", name, s, value)
+		}
+	}
+}
+
+// TestUpdateEvaluatesTargetOnce checks that compound assignment and ++/--
+// evaluate the object and index of their target only once.
+func TestUpdateEvaluatesTargetOnce(t *testing.T) {
+	interpreter := run(t, `
+		var calls = 0;
+		class Box { init() { this.count = 1; } }
+		var box = Box();
+		fun get() { calls++; return box; }
+		var field = get().count += 5;
+		var fieldPost = get().count++;
+		var fieldPre = --get().count;
+
+		var indexCalls = 0;
+		var list = [10, 20];
+		fun idx() { indexCalls++; return 1; }
+		var elementPost = list[idx()]++;
+		var elementPre = ++list[idx()];
+		list[idx()] *= 2;
+
+		var keyCalls = 0;
+		var counts = {"a": 1};
+		fun key() { keyCalls++; return "a"; }
+		counts[key()] += 10;
+		counts[key()]--;
+	`)
+	checkGlobals(t, interpreter, map[string]string{
+		"calls":       "3",
+		"field":       "6",
+		"fieldPost":   "6",
+		"fieldPre":    "6",
+		"indexCalls":  "3",
+		"elementPost": "20",
+		"elementPre":  "22",
+		"list":        "[10, 44]",
+		"keyCalls":    "2",
+		"counts":      `{"a": 10}`,
+	})
+	box, _ := interpreter.globals.Get("box")
+	if count, _ := box.(*Instance).Get("count"); interpreter.stringify(count) != "6" {
+		t.Errorf("box.count = %s, want 6", interpreter.stringify(count))
+	}
+}
+
+func TestIncrementResults(t *testing.T) {
+	interpreter := run(t, `
+		var n = 5;
+		var pre = ++n;
+		var post = n++;
+		var afterPost = n;
+		var down = n--;
+		var downPre = --n;
+		var d = 1.5d;
+		var decimalPost = d++;
+	`)
+	checkGlobals(t, interpreter, map[string]string{
+		"pre":         "6",
+		"post":        "6",
+		"afterPost":   "7",
+		"down":        "7",
+		"downPre":     "5",
+		"n":           "5",
+		"decimalPost": "1.5",
+		"d":           "2.5",
+	})
+}
+
+func TestRemainderAssignment(t *testing.T) {
+	interpreter := run(t, `
+		var a = 17; a %= 5;
+		var b = -17; b %= 5;
+		var c = 17; c %= -5;
+		var d = 17.5d; d %= 5;
+		var e = -7.25d; e %= 2d;
+		var f = 7.5; f %= 2;
+	`)
+	checkGlobals(t, interpreter, map[string]string{
+		"a": "2",
+		"b": "-2",
+		"c": "2",
+		"d": "2.5",
+		"e": "-1.25",
+		"f": "1.5",
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// List is a list value. Lists are shared, not copied: assigning a list or
+// passing it to a function gives another reference to the same list, and two
+// lists are equal only if they are the same list.
+//
+// Indexes start at 0 and negative ones count back from the end, so -1 is
+// the last element. Reading or writing an index outside the list is a
+// runtime error. Slices take the elements from start up to but not including
+// end and, like Python's, clamp out-of-range bounds instead of failing.
+type List struct {
+	elements []interface{}
+}
+
+func NewList(elements []interface{}) *List {
+	return &List{elements: elements}
+}
+
+// index returns the position value refers to. On failure message describes
+// the runtime error.
+func (l *List) index(value interface{}) (int, string) {
+	n, ok := value.(int64)
+	if !ok {
+		if _, ok := value.(*big.Int); !ok {
+			return 0, "List index must be an integer."
+		}
+		return 0, fmt.Sprintf("List index %s out of range for length %d.", formatNumber(value), len(l.elements))
+	}
+	position := n
+	if position < 0 {
+		position += int64(len(l.elements))
+	}
+	if position < 0 || position >= int64(len(l.elements)) {
+		return 0, fmt.Sprintf("List index %d out of range for length %d.", n, len(l.elements))
+	}
+	return int(position), ""
+}
+
+// sliceBound returns the position a slice bound refers to, clamped to the
+// list. A nil bound is the start or end of the list, as given by omitted.
+func (l *List) sliceBound(value interface{}, omitted int) (int, string) {
+	if value == nil {
+		return omitted, ""
+	}
+	length := int64(len(l.elements))
+	var n int64
+	switch v := value.(type) {
+	case int64:
+		n = v
+	case *big.Int:
+		// Too big for int64, so past one end or the other.
+		n = length
+		if v.Sign() < 0 {
+			n = -length
+		}
+	default:
+		return 0, "Slice bounds must be integers."
+	}
+	if n < 0 {
+		n += length
+	}
+	return int(min(max(n, 0), length)), ""
+}
+
+// Slice returns a new list holding the elements from start up to end.
+func (l *List) Slice(start, end interface{}) (*List, string) {
+	from, message := l.sliceBound(start, 0)
+	if message != "" {
+		return nil, message
+	}
+	to, message := l.sliceBound(end, len(l.elements))
+	if message != "" {
+		return nil, message
+	}
+	if to < from {
+		to = from
+	}
+	return NewList(append([]interface{}(nil), l.elements[from:to]...)), ""
+}
+
+// Get returns the built-in method called name, bound to the list:
+//
+//	push(value)  appends value and returns nil
+//	pop()        removes the last element and returns it
+//	len()        returns the number of elements
+func (l *List) Get(name string) (interface{}, bool) {
+	switch name {
+	case "push":
+		return &nativeFunction{arity: 1, fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			l.elements = append(l.elements, arguments[0])
+			return nil, nil
+		}}, true
+	case "pop":
+		return &nativeFunction{arity: 0, fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			if len(l.elements) == 0 {
+				return nil, NewRuntimeError(IndexError, "Can't pop from an empty list.")
+			}
+			last := l.elements[len(l.elements)-1]
+			l.elements = l.elements[:len(l.elements)-1]
+			return last, nil
+		}}, true
+	case "len":
+		return &nativeFunction{arity: 0, fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			return int64(len(l.elements)), nil
+		}}, true
+	}
+	return nil, false
+}
+
+// stringifyList renders a list as [1, "two", nil]. A list that contains
+// itself prints the inner reference as [...]; seen holds the lists and maps
+// already being printed.
+func (i *Interpreter) stringifyList(list *List, seen map[interface{}]bool) string {
+	if seen[list] {
+		return "[...]"
+	}
+	seen[list] = true
+	defer delete(seen, list)
+
+	var builder strings.Builder
+	builder.WriteString("[")
+	for n, element := range list.elements {
+		if n > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(i.stringifyElement(element, seen))
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
+
+// stringifyElement renders a value held in a list or map. Strings are quoted
+// so that ["a, b"] and ["a", "b"] print differently.
+func (i *Interpreter) stringifyElement(value interface{}, seen map[interface{}]bool) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case *List:
+		return i.stringifyList(v, seen)
+	case *Map:
+		return i.stringifyMap(v, seen)
+	}
+	return i.stringify(value)
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"flag"
 	"fmt"
+	"io"
+	"math/big"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"go-intepreter/gen"
 	"go-intepreter/tokens"
@@ -13,44 +21,345 @@ import (
 var hadError bool
 
 // scanner->start
+//
+// The scanner reads its source from an io.Reader and hands out tokens one at
+// a time through Next, so only the token and the line being scanned (and,
+// when trivia is kept, the token before it) have to be held in memory, along
+// with any lines kept for quoting in diagnostics.
 type Scanner struct {
-	Tokens []*tokens.Token
-	Source string
+	Diagnostics []Diagnostic
+
+	// PreserveTrivia makes the scanner attach whitespace, comments and
+	// skipped characters to the tokens around them, so that
+	// tokens.Reconstruct gives back the source exactly.
+	PreserveTrivia bool
+
+	// KeepLines makes the scanner keep every line of the source, so that
+	// errors found after scanning, such as runtime errors, can quote the line
+	// they are on. Without it only lines with a diagnostic are kept.
+	KeepLines bool
+
+	// buffer holds the source from the start of the token or the line being
+	// scanned, whichever is earlier, onward (and possibly some bytes before
+	// it); bufferStart is the offset of buffer[0] in the source.
+	reader      io.Reader
+	buffer      []byte
+	bufferStart int
+	readDone    bool
+
+	// lineBegin is the offset of the start of the current line. lines holds
+	// the finished lines that were kept, by line number.
+	lineBegin int
+	lines     map[int]sourceLine
 
 	start   int
 	current int
 	line    int
+
+	// column is the 1-based column of current, counted in runes rather than
+	// bytes. startLine and startColumn record where the token being scanned
+	// began, since strings may span several lines.
+	column      int
+	startLine   int
+	startColumn int
+
+	// interpolations has an entry for every "${" whose closing brace has not
+	// been reached yet, innermost last.
+	interpolations []interpolation
+
+	// queue holds scanned tokens Next has not returned yet and last is the
+	// most recently scanned one. done is set once EOF has been queued.
+	queue []*tokens.Token
+	last  *tokens.Token
+	done  bool
+
+	// leading collects trivia for the next token. trailing is set while
+	// trivia still belongs to the previous token, up to the end of its line.
+	leading  []tokens.Trivia
+	trailing bool
+}
+
+// interpolation tracks an open "${" so the scanner knows which '}' ends it.
+type interpolation struct {
+	depth int // braces opened inside the interpolated expression
+	open  tokens.Span
 }
 
 // NewScanner creates a new Scanner instance
 func NewScanner(source string) *Scanner {
+	return NewReaderScanner(strings.NewReader(source))
+}
+
+// NewReaderScanner creates a Scanner that reads its source from reader as
+// tokens are asked for.
+func NewReaderScanner(reader io.Reader) *Scanner {
 	return &Scanner{
-		Source:  source,
+		reader:  reader,
 		line:    1,
+		column:  1,
 		start:   0,
 		current: 0,
 	}
 }
 
-// ScanTokens scans all tokens in the source
-func (s *Scanner) ScanTokens() []*tokens.Token {
-	for !s.isAtEnd() {
-		s.start = s.current
-		s.scanToken()
+// ScanTokens scans all tokens in the source. Scanning carries on past bad
+// input; every problem found is returned alongside the tokens.
+func (s *Scanner) ScanTokens() ([]*tokens.Token, []Diagnostic) {
+	var all []*tokens.Token
+	for {
+		token := s.Next()
+		all = append(all, token)
+		if token.Type == tokens.EOF {
+			return all, s.Diagnostics
+		}
+	}
+}
+
+// Next returns the next token, reading only as much source as it needs.
+// Once the source is used up it keeps returning the EOF token. Problems
+// found on the way are added to Diagnostics.
+func (s *Scanner) Next() *tokens.Token {
+	for !s.ready() {
+		s.step()
 	}
+	token := s.queue[0]
+	if token.Type != tokens.EOF {
+		s.queue = s.queue[1:]
+	}
+	return token
+}
 
-	s.Tokens = append(s.Tokens, &tokens.Token{
-		Type:   tokens.EOF,
-		Lexeme: "",
-		Line:   s.line,
-	})
+// ready reports whether the first queued token is complete. With trivia
+// kept, a token's trailing trivia can grow until its line ends or another
+// token starts, so it is held back until then.
+func (s *Scanner) ready() bool {
+	if len(s.queue) == 0 {
+		return false
+	}
+	return !s.PreserveTrivia || len(s.queue) > 1 || !s.trailing || s.done
+}
+
+// step scans one token or piece of trivia, or queues EOF at the end.
+func (s *Scanner) step() {
+	s.start = s.current
+	s.startLine = s.line
+	s.startColumn = s.column
+	if s.isAtEnd() {
+		s.finish()
+		return
+	}
+
+	count := len(s.queue)
+	s.scanToken()
+	if s.PreserveTrivia {
+		s.collectTrivia(count)
+	}
+}
+
+// finish queues the EOF token.
+func (s *Scanner) finish() {
+	for _, interp := range s.interpolations {
+		s.Diagnostics = append(s.Diagnostics, Diagnostic{
+			Kind:    UnterminatedString,
+			Message: "Unterminated string interpolation.",
+			Span:    interp.open,
+		})
+	}
+	s.interpolations = nil
+
+	eof := &tokens.Token{
+		Type:          tokens.EOF,
+		Lexeme:        "",
+		Line:          s.line,
+		Column:        s.column,
+		Start:         s.current,
+		End:           s.current,
+		LeadingTrivia: s.leading,
+	}
+	s.queue = append(s.queue, eof)
+	s.last = eof
+	s.leading = nil
+	s.done = true
+}
+
+// collectTrivia runs after scanToken. If it produced a token the pending
+// leading trivia moves onto it; otherwise the text it consumed is trivia for
+// the previous token or the next one.
+func (s *Scanner) collectTrivia(count int) {
+	if len(s.queue) > count {
+		s.queue[count].LeadingTrivia = s.leading
+		s.leading = nil
+		s.trailing = true
+		return
+	}
+
+	text := s.text(s.start, s.current)
+	var kind tokens.TriviaKind
+	switch {
+	case text == "\n":
+		kind = tokens.NEWLINE
+	case strings.Trim(text, " \t\r") == "":
+		kind = tokens.WHITESPACE
+	case strings.HasPrefix(text, "//"):
+		kind = tokens.LINE_COMMENT
+	case strings.HasPrefix(text, "/*"):
+		kind = tokens.BLOCK_COMMENT
+	default:
+		kind = tokens.SKIPPED
+	}
+
+	list := &s.leading
+	if s.trailing && kind != tokens.NEWLINE && s.last != nil {
+		list = &s.last.TrailingTrivia
+	}
+	if n := len(*list); n > 0 && kind == tokens.WHITESPACE && (*list)[n-1].Kind == kind {
+		(*list)[n-1].Text += text
+	} else {
+		*list = append(*list, tokens.Trivia{Kind: kind, Text: text})
+	}
+	if strings.Contains(text, "\n") {
+		s.trailing = false
+	}
+}
+
+// fill tries to have n bytes from current buffered and reports whether it
+// managed to before the source ran out. Bytes before start and the start of
+// the current line are no longer needed and are dropped to make room.
+func (s *Scanner) fill(n int) bool {
+	for s.current-s.bufferStart+n > len(s.buffer) && !s.readDone {
+		if cap(s.buffer)-len(s.buffer) < readChunk {
+			keep := min(s.start, s.lineBegin)
+			if drop := keep - s.bufferStart; drop > 0 {
+				s.buffer = s.buffer[:copy(s.buffer, s.buffer[drop:])]
+				s.bufferStart = keep
+			}
+		}
+		if cap(s.buffer)-len(s.buffer) < readChunk {
+			grown := make([]byte, len(s.buffer), 2*cap(s.buffer)+readChunk)
+			copy(grown, s.buffer)
+			s.buffer = grown
+		}
+
+		read, err := s.reader.Read(s.buffer[len(s.buffer):cap(s.buffer)])
+		s.buffer = s.buffer[:len(s.buffer)+read]
+		if err == io.EOF {
+			s.readDone = true
+		} else if err != nil {
+			s.readDone = true
+			s.Diagnostics = append(s.Diagnostics, Diagnostic{
+				Kind:    ReadFailure,
+				Message: "Could not read source: " + err.Error(),
+				Span:    tokens.Span{Start: s.current, End: s.current, Line: s.line, Column: s.column},
+			})
+		}
+	}
+	return s.current-s.bufferStart+n <= len(s.buffer)
+}
+
+// readChunk is how many bytes the scanner asks its reader for at a time.
+const readChunk = 4096
+
+// text returns the source between two offsets at or after start.
+func (s *Scanner) text(from, to int) string {
+	return string(s.buffer[from-s.bufferStart : to-s.bufferStart])
+}
+
+// decode returns the rune at offset and its length in bytes, or size 0 at
+// the end of the source.
+func (s *Scanner) decode(offset int) (rune, int) {
+	s.fill(offset - s.current + utf8.UTFMax)
+	if offset-s.bufferStart >= len(s.buffer) {
+		return 0, 0
+	}
+	return utf8.DecodeRune(s.buffer[offset-s.bufferStart:])
+}
+
+// newline records that the character just consumed ended a line, keeping
+// the line if a diagnostic may need to quote it.
+func (s *Scanner) newline() {
+	end := s.current - 1
+	if s.KeepLines || s.start < end || s.diagnosed(s.line) {
+		if s.lines == nil {
+			s.lines = make(map[int]sourceLine)
+		}
+		s.lines[s.line] = sourceLine{text: s.text(s.lineBegin, end), begin: s.lineBegin}
+	}
+	s.line++
+	s.column = 1
+	s.lineBegin = s.current
+}
+
+// diagnosed reports whether a diagnostic was recorded on line, or on the line
+// an unfinished string interpolation opened on, which may get one at the end
+// of the source. A token running over several lines (s.start before the
+// line's end) can also get one once it is finished, so newline keeps its
+// lines too.
+func (s *Scanner) diagnosed(line int) bool {
+	for _, interp := range s.interpolations {
+		if interp.open.Line == line {
+			return true
+		}
+	}
+	// Diagnostics are recorded in source order, so any on line are among
+	// the last ones.
+	for n := len(s.Diagnostics) - 1; n >= 0 && s.Diagnostics[n].Span.Line >= line; n-- {
+		if s.Diagnostics[n].Span.Line == line {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLine is one line of the source, without its line ending, and the
+// offset it begins at.
+type sourceLine struct {
+	text  string
+	begin int
+}
+
+// SourceLine returns line number n of the source and the offset it begins
+// at, if the scanner still has it: a kept line, or the line being scanned,
+// which is read to its end if need be.
+func (s *Scanner) SourceLine(n int) (text string, begin int, ok bool) {
+	if line, ok := s.lines[n]; ok {
+		return line.text, line.begin, true
+	}
+	if n != s.line {
+		return "", 0, false
+	}
+	for {
+		rest := s.buffer[s.lineBegin-s.bufferStart:]
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			return string(rest[:i]), s.lineBegin, true
+		}
+		if !s.fill(len(rest) - (s.current - s.lineBegin) + 1) {
+			return string(s.buffer[s.lineBegin-s.bufferStart:]), s.lineBegin, true
+		}
+	}
+}
+
+// span returns the region covered by the token being scanned
+func (s *Scanner) span() tokens.Span {
+	return tokens.Span{
+		Start:  s.start,
+		End:    s.current,
+		Line:   s.startLine,
+		Column: s.startColumn,
+	}
+}
 
-	return s.Tokens
+// diagnostic records a problem with the token being scanned
+func (s *Scanner) diagnostic(kind DiagnosticKind, message string) {
+	s.Diagnostics = append(s.Diagnostics, Diagnostic{
+		Kind:    kind,
+		Message: message,
+		Span:    s.span(),
+	})
 }
 
 // isAtEnd checks if the scanner has reached the end of the source
 func (s *Scanner) isAtEnd() bool {
-	return s.current >= len(s.Source)
+	return !s.fill(1)
 }
 
 // scanToken scans a single token
@@ -62,25 +371,77 @@ func (s *Scanner) scanToken() {
 	case ')':
 		s.addToken(tokens.RIGHT_PAREN, nil)
 	case '{':
+		if n := len(s.interpolations); n > 0 {
+			s.interpolations[n-1].depth++
+		}
 		s.addToken(tokens.LEFT_BRACE, nil)
 	case '}':
+		if n := len(s.interpolations); n > 0 {
+			if s.interpolations[n-1].depth == 0 {
+				// This closes "${", so the string literal picks up again.
+				s.interpolations = s.interpolations[:n-1]
+				s.string()
+				break
+			}
+			s.interpolations[n-1].depth--
+		}
 		s.addToken(tokens.RIGHT_BRACE, nil)
+	case '[':
+		s.addToken(tokens.LEFT_BRACKET, nil)
+	case ']':
+		s.addToken(tokens.RIGHT_BRACKET, nil)
 	case ',':
 		s.addToken(tokens.COMMA, nil)
 	case '.':
-		s.addToken(tokens.DOT, nil)
+		if s.match('.') {
+			if s.match('=') {
+				s.addToken(tokens.DOT_DOT_EQUAL, nil)
+			} else {
+				s.addToken(tokens.DOT_DOT, nil)
+			}
+		} else {
+			s.addToken(tokens.DOT, nil)
+		}
 	case '-':
-		s.addToken(tokens.MINUS, nil)
+		if s.match('-') {
+			s.addToken(tokens.MINUS_MINUS, nil)
+		} else if s.match('=') {
+			s.addToken(tokens.MINUS_EQUAL, nil)
+		} else {
+			s.addToken(tokens.MINUS, nil)
+		}
 	case '+':
-		s.addToken(tokens.PLUS, nil)
+		if s.match('+') {
+			s.addToken(tokens.PLUS_PLUS, nil)
+		} else if s.match('=') {
+			s.addToken(tokens.PLUS_EQUAL, nil)
+		} else {
+			s.addToken(tokens.PLUS, nil)
+		}
 	case ';':
 		s.addToken(tokens.SEMICOLON, nil)
 	case '*':
-		s.addToken(tokens.STAR, nil)
+		if s.match('=') {
+			s.addToken(tokens.STAR_EQUAL, nil)
+		} else {
+			s.addToken(tokens.STAR, nil)
+		}
+	case '%':
+		if s.match('=') {
+			s.addToken(tokens.PERCENT_EQUAL, nil)
+		} else {
+			s.addToken(tokens.PERCENT, nil)
+		}
+	case '?':
+		s.addToken(tokens.QUESTION, nil)
+	case ':':
+		s.addToken(tokens.COLON, nil)
 	case '=':
 		var enumval tokens.TokenType
 		if s.match('=') {
 			enumval = tokens.EQUAL_EQUAL
+		} else if s.match('>') {
+			enumval = tokens.ARROW
 		} else {
 			enumval = tokens.EQUAL
 		}
@@ -114,11 +475,15 @@ func (s *Scanner) scanToken() {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+		} else if s.match('*') {
+			s.blockComment()
+		} else if s.match('=') {
+			s.addToken(tokens.SLASH_EQUAL, nil)
 		} else {
 			s.addToken(tokens.SLASH, nil)
 		}
 	case '\n':
-		s.line++
+		s.newline()
 		break
 	case '\t':
 		break
@@ -131,90 +496,296 @@ func (s *Scanner) scanToken() {
 		break
 	default:
 		if s.isDigit(c) {
-			s.number()
+			s.number(c)
 		} else if s.isAlpha(c) {
 			s.identifer()
+		} else if c == utf8.RuneError && s.current-s.start == 1 {
+			s.diagnostic(InvalidEncoding, "Invalid UTF-8 encoding.")
 		} else {
-			error(s.line, "Unexpected character:", string(c))
-			os.Exit(1) // Terminate on unexpected characters
+			s.diagnostic(UnexpectedCharacter, "Unexpected character: "+string(c))
 		}
 	}
 }
 
-func (s *Scanner) advance() byte {
-	c := s.Source[s.current]
-	s.current++
+// advance consumes one rune. Bytes that are not valid UTF-8 are consumed
+// one at a time and come back as utf8.RuneError.
+func (s *Scanner) advance() rune {
+	c, size := s.decode(s.current)
+	s.current += size
+	s.column++
 	return c
 }
 
-func (s *Scanner) match(nextExpected byte) bool {
+func (s *Scanner) match(nextExpected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
-	c := s.Source[s.current]
-	if c != nextExpected {
+	if s.peek() != nextExpected {
 		return false
 	}
-	s.current++
+	s.advance()
 	return true
 }
 
-func (s *Scanner) peek() byte {
+func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return '\n'
 	} else {
-		return s.Source[s.current]
+		c, _ := s.decode(s.current)
+		return c
 	}
 }
 
+// blockComment skips a /* ... */ comment whose opening "/*" has just been
+// consumed. Block comments nest, so every "/*" inside needs its own "*/".
+func (s *Scanner) blockComment() {
+	open := s.span()
+	depth := 1
+	for depth > 0 && !s.isAtEnd() {
+		c := s.advance()
+		switch {
+		case c == '\n':
+			s.newline()
+		case c == '/' && s.peek() == '*':
+			s.advance()
+			depth++
+		case c == '*' && s.peek() == '/':
+			s.advance()
+			depth--
+		}
+	}
+
+	if depth > 0 {
+		s.Diagnostics = append(s.Diagnostics, Diagnostic{
+			Kind:    UnterminatedComment,
+			Message: "Unterminated block comment.",
+			Span:    open,
+		})
+	}
+}
+
+// string scans the rest of a string literal, or the part of one up to the
+// next "${", processing escape sequences as it goes.
 func (s *Scanner) string() {
+	var value strings.Builder
 	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
+		c := s.advance()
+		switch {
+		case c == '\n':
+			s.newline()
+			value.WriteRune(c)
+		case c == '\\':
+			s.escape(&value)
+		case c == '$' && s.peek() == '{':
+			s.advance()
+			s.interpolations = append(s.interpolations, interpolation{
+				open: tokens.Span{Start: s.current - 2, End: s.current, Line: s.line, Column: s.column - 2},
+			})
+			s.addToken(tokens.INTERPOLATION, value.String())
+			return
+		default:
+			value.WriteRune(c)
 		}
-		s.advance()
 	}
 
 	if s.isAtEnd() {
-		error(s.line, "Unterminated string.", "")
+		s.diagnostic(UnterminatedString, "Unterminated string.")
 		return
 	}
 
 	s.advance()
 
-	value := s.Source[s.start+1 : s.current-1]
-	s.addToken(tokens.STRING, value)
+	s.addToken(tokens.STRING, value.String())
+}
+
+// escape decodes the escape sequence following a backslash into value.
+// Supported are \n, \t, \r, \0, \", \\, \$ and \u{XXXX} with one to six hex
+// digits. An invalid sequence is reported and dropped from the string.
+func (s *Scanner) escape(value *strings.Builder) {
+	start := tokens.Span{Start: s.current - 1, Line: s.line, Column: s.column - 1}
+	invalid := func(message string) {
+		start.End = s.current
+		s.Diagnostics = append(s.Diagnostics, Diagnostic{
+			Kind:    InvalidEscape,
+			Message: message,
+			Span:    start,
+		})
+	}
+
+	if s.isAtEnd() {
+		return
+	}
+	c := s.advance()
+	switch c {
+	case 'n':
+		value.WriteByte('\n')
+	case 't':
+		value.WriteByte('\t')
+	case 'r':
+		value.WriteByte('\r')
+	case '0':
+		value.WriteByte(0)
+	case '"', '\\', '$':
+		value.WriteRune(c)
+	case 'u':
+		if !s.match('{') {
+			invalid("Expect '{' after \\u.")
+			return
+		}
+		code, digits := 0, 0
+		for s.isHexDigit(s.peek()) && digits < 6 {
+			code = code*16 + hexValue(s.advance())
+			digits++
+		}
+		if !s.match('}') {
+			invalid("Expect one to six hex digits and '}' in \\u{...}.")
+			return
+		}
+		if digits == 0 || !utf8.ValidRune(rune(code)) {
+			invalid("Invalid Unicode code point in escape sequence.")
+			return
+		}
+		value.WriteRune(rune(code))
+	default:
+		if c == '\n' {
+			s.newline()
+		}
+		invalid("Invalid escape sequence.")
+	}
+}
+
+func (s *Scanner) isHexDigit(c rune) bool {
+	return s.isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
 }
 
-func (s *Scanner) isDigit(c byte) bool {
+// hexValue returns the value of a hex digit accepted by isHexDigit.
+func hexValue(c rune) int {
+	switch {
+	case c >= 'a':
+		return int(c-'a') + 10
+	case c >= 'A':
+		return int(c-'A') + 10
+	}
+	return int(c - '0')
+}
+
+func (s *Scanner) isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
-func (s *Scanner) number() {
-	for s.isDigit(s.peek()) {
+// number scans a numeric literal whose first digit has been consumed.
+// Accepted forms are decimal with optional fraction and exponent (1.5e-9),
+// and integers with a 0x, 0o or 0b prefix. Underscores may separate digits
+// (1_000_000). The token's literal is an int64 (a *big.Int if it does not
+// fit), or a float64 if the literal has a fraction or exponent. A decimal
+// literal ends in d (12.50d) and has a *Decimal literal.
+func (s *Scanner) number(first rune) {
+	base := 10
+	if first == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+	}
+
+	var digits strings.Builder
+	valid, isFloat := true, false
+	if base == 10 {
+		digits.WriteRune(first)
+		s.digits(10, &digits, true)
+
+		if s.peek() == '.' && s.isDigit(s.nextPeek()) {
+			isFloat = true
+			digits.WriteRune(s.advance())
+			s.digits(10, &digits, false)
+		}
+
+		if s.peek() == 'e' || s.peek() == 'E' {
+			isFloat = true
+			digits.WriteRune(s.advance())
+			if s.peek() == '+' || s.peek() == '-' {
+				digits.WriteRune(s.advance())
+			}
+			valid = s.digits(10, &digits, false)
+		}
+	} else {
 		s.advance()
+		valid = s.digits(base, &digits, false)
 	}
 
-	if s.peek() == '.' && s.isDigit(s.nextPeek()) {
+	isDecimal := false
+	if base == 10 && s.peek() == 'd' && !s.isAlphaNumeric(s.nextPeek()) {
 		s.advance()
+		isDecimal = true
+	}
 
-		for s.isDigit(s.peek()) {
-			s.advance()
-		}
+	// Letters, digits or underscores running straight on from the literal
+	// (1e, 0b102, 7_, 12abc) make the whole run invalid.
+	for s.isAlphaNumeric(s.peek()) {
+		s.advance()
+		valid = false
+	}
+	if !valid {
+		s.diagnostic(InvalidNumber, "Invalid numeric literal.")
+		s.addToken(tokens.NUMBER, int64(0))
+		return
 	}
-	convvalue, _ := strconv.ParseFloat(s.Source[s.start:s.current], 64)
-	// Format the value
-	var formattedValue string
-	if convvalue == float64(int64(convvalue)) {
-		// If the value is an integer, format with one decimal place
-		formattedValue = fmt.Sprintf("%.1f", convvalue)
+
+	var value interface{}
+	var outOfRange bool
+	if isDecimal {
+		d, ok := parseDecimal(digits.String())
+		value, outOfRange = d, !ok
+	} else if isFloat {
+		f, err := strconv.ParseFloat(digits.String(), 64)
+		value, outOfRange = f, err != nil
 	} else {
-		// Otherwise, use the full precision as is
-		formattedValue = fmt.Sprintf("%v", convvalue)
+		n, err := strconv.ParseInt(digits.String(), base, 64)
+		value = n
+		if err != nil {
+			// Too big for int64, so the literal starts out as a big integer.
+			value, _ = new(big.Int).SetString(digits.String(), base)
+		}
+	}
+	if outOfRange {
+		s.diagnostic(InvalidNumber, "Numeric literal out of range.")
+	}
+
+	s.addToken(tokens.NUMBER, value)
+}
+
+// digits consumes digits of the given base into b, skipping underscores
+// that sit between two digits. It reports whether at least one digit was
+// read; afterDigit says whether a digit directly precedes the first one.
+func (s *Scanner) digits(base int, b *strings.Builder, afterDigit bool) bool {
+	read := false
+	for {
+		c := s.peek()
+		if s.isDigitOf(c, base) {
+			b.WriteRune(s.advance())
+			read, afterDigit = true, true
+		} else if c == '_' && afterDigit && s.isDigitOf(s.nextPeek(), base) {
+			s.advance()
+		} else {
+			return read
+		}
 	}
+}
 
-	// Add the formatted value as a token
-	s.addToken(tokens.NUMBER, formattedValue)
+func (s *Scanner) isDigitOf(c rune, base int) bool {
+	switch base {
+	case 2:
+		return c == '0' || c == '1'
+	case 8:
+		return c >= '0' && c <= '7'
+	case 16:
+		return s.isHexDigit(c)
+	}
+	return s.isDigit(c)
 }
 
 func (s *Scanner) identifer() {
@@ -222,7 +793,7 @@ func (s *Scanner) identifer() {
 		s.advance()
 	}
 
-	text := s.Source[s.start:s.current]
+	text := s.text(s.start, s.current)
 	tokenType := tokens.Keywords[text]
 	if tokenType == "" {
 		tokenType = tokens.IDENTIFIER
@@ -231,47 +802,77 @@ func (s *Scanner) identifer() {
 	s.addToken(tokenType, nil)
 }
 
-func (s *Scanner) isAlpha(c byte) bool {
-	return ((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c == '_'))
+// isAlpha reports whether c can start an identifier. Identifiers follow the
+// Unicode default identifier syntax (UAX #31): they start with a letter
+// (categories L and Nl, plus Other_ID_Start) or an underscore.
+func (s *Scanner) isAlpha(c rune) bool {
+	if c < utf8.RuneSelf {
+		return ((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c == '_'))
+	}
+	return unicode.In(c, unicode.L, unicode.Nl, unicode.Other_ID_Start)
 }
 
-func (s *Scanner) isAlphaNumeric(c byte) bool {
-	return (s.isAlpha(c) || s.isDigit(c))
+// isAlphaNumeric reports whether c can continue an identifier: anything that
+// can start one, plus digits (Nd), combining marks (Mn, Mc), connector
+// punctuation (Pc) and Other_ID_Continue.
+func (s *Scanner) isAlphaNumeric(c rune) bool {
+	if s.isAlpha(c) || s.isDigit(c) {
+		return true
+	}
+	return c >= utf8.RuneSelf && unicode.In(c, unicode.Nd, unicode.Mn, unicode.Mc, unicode.Pc, unicode.Other_ID_Continue)
 }
 
-func (s *Scanner) nextPeek() byte {
-	if s.current+1 <= len(s.Source) {
-		return s.Source[s.current+1]
+// nextPeek returns the rune after the one peek returns.
+func (s *Scanner) nextPeek() rune {
+	if s.isAtEnd() {
+		return '\n'
 	}
-	return '"'
+	_, size := s.decode(s.current)
+	c, nextSize := s.decode(s.current + size)
+	if nextSize == 0 {
+		return '\n'
+	}
+	return c
 }
 
 func (s *Scanner) addToken(tokenType tokens.TokenType, literal interface{}) {
-	text := s.Source[s.start:s.current]
-	s.Tokens = append(s.Tokens, &tokens.Token{
+	token := &tokens.Token{
 		Type:    tokenType,
-		Lexeme:  text,
+		Lexeme:  s.text(s.start, s.current),
 		Literal: literal,
-		Line:    s.line,
-	})
+		Line:    s.startLine,
+		Column:  s.startColumn,
+		Start:   s.start,
+		End:     s.current,
+	}
+	s.queue = append(s.queue, token)
+	s.last = token
 }
 
 //scanner -> end
 
 // error logs->start
-func error(line int, message string, value string) {
-	report(line, "", message, value)
+func error(span tokens.Span, message string, value string) {
+	report(span, "", message, value)
 	hadError = true
 }
 
-// report formats and logs the error message to stderr.
-func report(line int, where string, message string, value string) {
+// warning reports a problem that does not stop the program from running.
+func warning(span tokens.Span, message string) {
+	fmt.Fprintf(os.Stderr, "[line %d] Warning: %s\n", span.Line, message)
+	quoteSource(os.Stderr, span)
+}
+
+// report formats and logs the error message to stderr, followed by the
+// offending source line with the span underlined.
+func report(span tokens.Span, where string, message string, value string) {
 	if value != "" {
-		fmt.Fprintf(os.Stderr, "[line %d] Error%s: %s %s\n", line, where, message, value)
+		fmt.Fprintf(os.Stderr, "[line %d] Error%s: %s %s\n", span.Line, where, message, value)
 
 	} else {
-		fmt.Fprintf(os.Stderr, "[line %d] Error%s: %s\n", line, where, message)
+		fmt.Fprintf(os.Stderr, "[line %d] Error%s: %s\n", span.Line, where, message)
 	}
+	quoteSource(os.Stderr, span)
 }
 
 // AST expr
@@ -326,6 +927,9 @@ func (a *ASTPrinter) VisitLiteralExpr(expr *gen.Literal) interface{} {
 	if expr.Value == nil {
 		return "nil"
 	}
+	if isNumber(expr.Value) {
+		return formatNumber(expr.Value)
+	}
 	return fmt.Sprintf("%v", expr.Value)
 }
 
@@ -333,131 +937,1124 @@ func (a *ASTPrinter) VisitUnaryExpr(expr *gen.Unary) interface{} {
 	return a.Paranthesize(expr.Operator.Lexeme, expr.Right)
 }
 
-func (v *ASTPrinter) Paranthesize(name string, exprs ...gen.Expr) string {
-    var builder strings.Builder
-
-    builder.WriteString("(" + name)
-    for _, expr := range exprs {
-        if expr != nil {
-            result := expr.Accept(v)
-            if str, ok := result.(string); ok {
-                builder.WriteString(" ")
-                builder.WriteString(str)
-            } else {
-                builder.WriteString(" <error: non-string result> ")
-            }
-        } else {
-            builder.WriteString(" <nil> ")
-        }
-    }
-    builder.WriteString(")")
+func (a *ASTPrinter) VisitInterpolationExpr(expr *gen.Interpolation) interface{} {
+	return a.Paranthesize("interpolate", expr.Parts...)
+}
 
-    return builder.String()
+func (a *ASTPrinter) VisitExpressionStmt(stmt *gen.Expression) interface{} {
+	return a.Paranthesize(";", stmt.Expression)
 }
-// Parser start===>
-type Parser struct {
-	tokens  []*tokens.Token
-	current int
+
+func (a *ASTPrinter) VisitPrintStmt(stmt *gen.Print) interface{} {
+	return a.Paranthesize("print", stmt.Expression)
 }
 
-func NewParser(tokens []*tokens.Token) *Parser {
-	return &Parser{
-		tokens:  tokens,
-		current: 0,
+func (a *ASTPrinter) VisitVarStmt(stmt *gen.Var) interface{} {
+	if stmt.Initializer == nil {
+		return "(var " + stmt.Name.Lexeme + ")"
 	}
+	return a.Paranthesize("var "+stmt.Name.Lexeme, stmt.Initializer)
 }
 
-func (p *Parser) expression() gen.Expr {
-	return p.equality()
+func (a *ASTPrinter) VisitVariableExpr(expr *gen.Variable) interface{} {
+	return expr.Name.Lexeme
 }
 
-func (p *Parser) equality() gen.Expr {
-	expr := p.comparison()
+func (a *ASTPrinter) VisitAssignExpr(expr *gen.Assign) interface{} {
+	return a.Paranthesize("= "+expr.Name.Lexeme, expr.Value)
+}
 
-	for p.match(tokens.BANG_EQUAL, tokens.EQUAL_EQUAL) {
-		op := p.previous()
-		right := p.comparison()
-		expr = gen.NewBinary(expr, right, op)
+func (a *ASTPrinter) VisitLogicalExpr(expr *gen.Logical) interface{} {
+	return a.Paranthesize(expr.Operator.Lexeme, expr.Left, expr.Right)
+}
+
+func (a *ASTPrinter) VisitIfStmt(stmt *gen.If) interface{} {
+	result := "(if " + stmt.Condition.Accept(a).(string) + " " + stmt.ThenBranch.Accept(a).(string)
+	if stmt.ElseBranch != nil {
+		result += " " + stmt.ElseBranch.Accept(a).(string)
 	}
-	return expr
+	return result + ")"
 }
 
-func (p *Parser) comparison() gen.Expr {
-	expr := p.term()
+func (a *ASTPrinter) VisitWhileStmt(stmt *gen.While) interface{} {
+	return "(while " + stmt.Condition.Accept(a).(string) + " " + stmt.Body.Accept(a).(string) + ")"
+}
 
-	for p.match(tokens.GREATER, tokens.GREATER_EQUAL, tokens.LESS, tokens.LESS_EQUAL) {
-		op := p.previous()
-		right := p.term()
-		expr = gen.NewBinary(expr, right, op)
-	}
-	return expr
+func (a *ASTPrinter) VisitCallExpr(expr *gen.Call) interface{} {
+	return a.Paranthesize("call", append([]gen.Expr{expr.Callee}, expr.Arguments...)...)
 }
 
-func (p *Parser) term() gen.Expr {
-	expr := p.factor()
+func (a *ASTPrinter) VisitConditionalExpr(expr *gen.Conditional) interface{} {
+	return a.Paranthesize("?:", expr.Condition, expr.ThenBranch, expr.ElseBranch)
+}
 
-	for p.match(tokens.MINUS, tokens.PLUS) {
-		op := p.previous()
-		right := p.factor()
-		expr = gen.NewBinary(expr, right, op)
-	}
-	return expr
+func (a *ASTPrinter) VisitCommaExpr(expr *gen.Comma) interface{} {
+	return a.Paranthesize(",", expr.Left, expr.Right)
 }
 
-func (p *Parser) factor() gen.Expr {
-	expr := p.unary()
+func (a *ASTPrinter) VisitCompoundExpr(expr *gen.Compound) interface{} {
+	return a.Paranthesize(expr.Operator.Lexeme, expr.Target, expr.Value)
+}
 
-	for p.match(tokens.SLASH, tokens.STAR) {
-		op := p.previous()
-		right := p.unary()
-		expr = gen.NewBinary(expr, right, op)
+func (a *ASTPrinter) VisitIncrementExpr(expr *gen.Increment) interface{} {
+	if expr.Prefix {
+		return a.Paranthesize(expr.Operator.Lexeme, expr.Target)
 	}
-	return expr
+	return a.Paranthesize("post"+expr.Operator.Lexeme, expr.Target)
 }
 
-func (p *Parser) unary() gen.Expr {
-	if p.match(tokens.BANG, tokens.MINUS) {
-		op := p.previous()
-		right := p.unary()
-		return gen.NewUnary(op, right)
+func (a *ASTPrinter) VisitListExpr(expr *gen.List) interface{} {
+	return a.Paranthesize("list", expr.Elements...)
+}
+
+func (a *ASTPrinter) VisitMapExpr(expr *gen.Map) interface{} {
+	var entries []gen.Expr
+	for n := range expr.Keys {
+		entries = append(entries, expr.Keys[n], expr.Values[n])
 	}
-	return p.primary()
+	return a.Paranthesize("map", entries...)
 }
 
-func (p *Parser) primary() gen.Expr {
-	if p.match(tokens.FALSE) {
-		return gen.NewLiteral(false)
+func (a *ASTPrinter) VisitMatchExpr(expr *gen.Match) interface{} {
+	var builder strings.Builder
+	builder.WriteString("(match " + expr.Subject.Accept(a).(string))
+	for _, arm := range expr.Arms {
+		builder.WriteString(" (" + arm.Pattern.Accept(a).(string))
+		if arm.Guard != nil {
+			builder.WriteString(" if " + arm.Guard.Accept(a).(string))
+		}
+		builder.WriteString(" " + arm.Body.Accept(a).(string) + ")")
 	}
-	if p.match(tokens.TRUE) {
-		return gen.NewLiteral(true)
+	builder.WriteString(")")
+	return builder.String()
+}
+
+// Patterns print much as they are written.
+
+func (a *ASTPrinter) VisitConstantPattern(pattern *gen.Constant) interface{} {
+	return gen.NewLiteral(pattern.Value, pattern.Token).Accept(a)
+}
+
+func (a *ASTPrinter) VisitRangePattern(pattern *gen.Range) interface{} {
+	var low, high string
+	if pattern.Low != nil {
+		low = formatNumber(pattern.Low)
 	}
-	if p.match(tokens.NIL) {
-		return gen.NewLiteral(nil)
+	if pattern.High != nil {
+		high = formatNumber(pattern.High)
 	}
-	if p.match(tokens.NUMBER, tokens.STRING) {
-		return gen.NewLiteral(p.previous().Literal)
+	return low + pattern.Operator.Lexeme + high
+}
+
+func (a *ASTPrinter) VisitTypeTestPattern(pattern *gen.TypeTest) interface{} {
+	if pattern.Name == nil {
+		return "is " + pattern.TypeName.Lexeme
 	}
-	if p.match(tokens.LEFT_PAREN) {
-		expr := p.expression()
+	return pattern.Name.Lexeme + " is " + pattern.TypeName.Lexeme
+}
+
+func (a *ASTPrinter) VisitBindingPattern(pattern *gen.Binding) interface{} {
+	return pattern.Name.Lexeme
+}
+
+func (a *ASTPrinter) VisitWildcardPattern(pattern *gen.Wildcard) interface{} {
+	return "_"
+}
+
+func (a *ASTPrinter) VisitListDestructurePattern(pattern *gen.ListDestructure) interface{} {
+	var parts []string
+	for _, element := range pattern.Before {
+		parts = append(parts, element.Accept(a).(string))
+	}
+	if pattern.Rest != nil {
+		rest := ".."
+		if pattern.Rest.Type == tokens.IDENTIFIER {
+			rest += pattern.Rest.Lexeme
+		}
+		parts = append(parts, rest)
+	}
+	for _, element := range pattern.After {
+		parts = append(parts, element.Accept(a).(string))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func (a *ASTPrinter) VisitMapDestructurePattern(pattern *gen.MapDestructure) interface{} {
+	var parts []string
+	for n := range pattern.Keys {
+		parts = append(parts, pattern.Keys[n].Accept(a).(string)+": "+pattern.Values[n].Accept(a).(string))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func (a *ASTPrinter) VisitIndexExpr(expr *gen.Index) interface{} {
+	return a.Paranthesize("index", expr.Object, expr.Index)
+}
+
+// VisitSliceExpr prints an omitted bound as _.
+func (a *ASTPrinter) VisitSliceExpr(expr *gen.Slice) interface{} {
+	bound := func(expr gen.Expr) string {
+		if expr == nil {
+			return "_"
+		}
+		return expr.Accept(a).(string)
+	}
+	return "(slice " + expr.Object.Accept(a).(string) + " " + bound(expr.Start) + " " + bound(expr.End) + ")"
+}
+
+func (a *ASTPrinter) VisitSetIndexExpr(expr *gen.SetIndex) interface{} {
+	return a.Paranthesize("set-index", expr.Object, expr.Index, expr.Value)
+}
+
+func (a *ASTPrinter) VisitGetExpr(expr *gen.Get) interface{} {
+	return "(get " + expr.Object.Accept(a).(string) + " " + expr.Name.Lexeme + ")"
+}
+
+func (a *ASTPrinter) VisitSetExpr(expr *gen.Set) interface{} {
+	return "(set " + expr.Object.Accept(a).(string) + " " + expr.Name.Lexeme + " " + expr.Value.Accept(a).(string) + ")"
+}
+
+func (a *ASTPrinter) VisitThisExpr(expr *gen.This) interface{} {
+	return "this"
+}
+
+func (a *ASTPrinter) VisitSuperExpr(expr *gen.Super) interface{} {
+	return "(super " + expr.Method.Lexeme + ")"
+}
+
+func (a *ASTPrinter) VisitClassStmt(stmt *gen.Class) interface{} {
+	var builder strings.Builder
+	builder.WriteString("(class " + stmt.Name.Lexeme)
+	if stmt.Superclass != nil {
+		builder.WriteString(" < " + stmt.Superclass.Name.Lexeme)
+	}
+	for _, method := range stmt.Methods {
+		builder.WriteString(" ")
+		builder.WriteString(method.Accept(a).(string))
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+func (a *ASTPrinter) VisitFunctionStmt(stmt *gen.Function) interface{} {
+	var builder strings.Builder
+	builder.WriteString("(fun " + stmt.Name.Lexeme + " (")
+	for n, param := range stmt.Params {
+		if n > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(param.Lexeme)
+	}
+	builder.WriteString(")")
+	for _, statement := range stmt.Body {
+		builder.WriteString(" ")
+		builder.WriteString(statement.Accept(a).(string))
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+func (a *ASTPrinter) VisitReturnStmt(stmt *gen.Return) interface{} {
+	if stmt.Value == nil {
+		return "(return)"
+	}
+	return a.Paranthesize("return", stmt.Value)
+}
+
+func (a *ASTPrinter) VisitThrowStmt(stmt *gen.Throw) interface{} {
+	return a.Paranthesize("throw", stmt.Value)
+}
+
+// VisitTryStmt prints a try statement as (try (block ...) (catch name ...)
+// (finally ...)), leaving out the clauses it does not have.
+func (a *ASTPrinter) VisitTryStmt(stmt *gen.Try) interface{} {
+	result := "(try " + a.VisitBlockStmt(gen.NewBlock(stmt.Body)).(string)
+	if stmt.CatchBody != nil {
+		result += " (catch"
+		if stmt.CatchName != nil {
+			result += " " + stmt.CatchName.Lexeme
+		}
+		for _, statement := range stmt.CatchBody {
+			result += " " + statement.Accept(a).(string)
+		}
+		result += ")"
+	}
+	if stmt.FinallyBody != nil {
+		result += " (finally"
+		for _, statement := range stmt.FinallyBody {
+			result += " " + statement.Accept(a).(string)
+		}
+		result += ")"
+	}
+	return result + ")"
+}
+
+func (a *ASTPrinter) VisitBlockStmt(stmt *gen.Block) interface{} {
+	var builder strings.Builder
+	builder.WriteString("(block")
+	for _, statement := range stmt.Statements {
+		builder.WriteString(" ")
+		builder.WriteString(statement.Accept(a).(string))
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+func (v *ASTPrinter) Paranthesize(name string, exprs ...gen.Expr) string {
+    var builder strings.Builder
+
+    builder.WriteString("(" + name)
+    for _, expr := range exprs {
+        if expr != nil {
+            result := expr.Accept(v)
+            if str, ok := result.(string); ok {
+                builder.WriteString(" ")
+                builder.WriteString(str)
+            } else {
+                builder.WriteString(" <error: non-string result> ")
+            }
+        } else {
+            builder.WriteString(" <nil> ")
+        }
+    }
+    builder.WriteString(")")
+
+    return builder.String()
+}
+// Parser start===>
+
+// tokenSource hands the parser tokens one at a time. *Scanner is one, so
+// parsing can start before the whole source has been read.
+type tokenSource interface {
+	Next() *tokens.Token
+}
+
+// Parser keeps just the token it is looking at and the one before it.
+type Parser struct {
+	source    tokenSource
+	lookahead *tokens.Token
+	prev      *tokens.Token
+
+	errors []*ParseError
+	// reported is how many of the scanner's diagnostics have been printed,
+	// when source is a *Scanner.
+	reported int
+}
+
+func NewParser(source tokenSource) *Parser {
+	p := &Parser{source: source}
+	p.lookahead = p.next()
+	return p
+}
+
+// next pulls the next token from the source. When the source is a scanner,
+// the problems it found while scanning the token are printed straight away,
+// so they come out in source order with the syntax errors they cause.
+func (p *Parser) next() *tokens.Token {
+	token := p.source.Next()
+	if scanner, ok := p.source.(*Scanner); ok {
+		reportDiagnostics(scanner.Diagnostics[p.reported:])
+		p.reported = len(scanner.Diagnostics)
+	}
+	return token
+}
+
+func (p *Parser) expression() gen.Expr {
+	return p.comma()
+}
+
+// comma is the lowest precedence operator. Places where a comma already
+// means something else, such as between call arguments, parse an assignment
+// instead so the comma is left for them.
+func (p *Parser) comma() gen.Expr {
+	expr := p.assignment()
+
+	for p.match(tokens.COMMA) {
+		right := p.assignment()
+		expr = gen.NewComma(expr, right)
+	}
+	return expr
+}
+
+// assignment parses the target as an ordinary expression and only then
+// checks, once it sees '=', that it names something assignable.
+func (p *Parser) assignment() gen.Expr {
+	expr := p.conditional()
+
+	if p.match(tokens.EQUAL) {
+		equals := p.previous()
+		value := p.assignment()
+
+		switch target := expr.(type) {
+		case *gen.Variable:
+			return gen.NewAssign(target.Name, value)
+		case *gen.Get:
+			return gen.NewSet(target.Object, target.Name, value)
+		case *gen.Index:
+			return gen.NewSetIndex(target.Object, target.Bracket, target.Index, value)
+		}
+		// Reported but not thrown: the parser is not confused, so there is
+		// no need to synchronize.
+		p.error(equals, "Invalid assignment target.")
+	} else if p.match(tokens.PLUS_EQUAL, tokens.MINUS_EQUAL, tokens.STAR_EQUAL, tokens.SLASH_EQUAL, tokens.PERCENT_EQUAL) {
+		operator := p.previous()
+		value := p.assignment()
+		if !isAssignable(expr) {
+			p.error(operator, "Invalid assignment target.")
+			return expr
+		}
+		return gen.NewCompound(expr, operator, value)
+	}
+	return expr
+}
+
+// isAssignable reports whether expr can be the target of a compound
+// assignment or an increment.
+func isAssignable(expr gen.Expr) bool {
+	switch expr.(type) {
+	case *gen.Variable, *gen.Get, *gen.Index:
+		return true
+	}
+	return false
+}
+
+// conditional parses cond ? then : else. It is right-associative, so
+// a ? b : c ? d : e groups as a ? b : (c ? d : e). As in C, the middle
+// operand can be any expression since the ':' ends it.
+func (p *Parser) conditional() gen.Expr {
+	expr := p.or()
+
+	if p.match(tokens.QUESTION) {
+		thenBranch := p.expression()
+		p.consume(tokens.COLON, "Expect ':' after then branch of conditional expression.")
+		elseBranch := p.conditional()
+		expr = gen.NewConditional(expr, thenBranch, elseBranch)
+	}
+	return expr
+}
+
+func (p *Parser) or() gen.Expr {
+	expr := p.and()
+
+	for p.match(tokens.OR) {
+		op := p.previous()
+		right := p.and()
+		expr = gen.NewLogical(expr, right, op)
+	}
+	return expr
+}
+
+func (p *Parser) and() gen.Expr {
+	expr := p.equality()
+
+	for p.match(tokens.AND) {
+		op := p.previous()
+		right := p.equality()
+		expr = gen.NewLogical(expr, right, op)
+	}
+	return expr
+}
+
+func (p *Parser) equality() gen.Expr {
+	expr := p.comparison()
+
+	for p.match(tokens.BANG_EQUAL, tokens.EQUAL_EQUAL) {
+		op := p.previous()
+		right := p.comparison()
+		expr = gen.NewBinary(expr, right, op)
+	}
+	return expr
+}
+
+func (p *Parser) comparison() gen.Expr {
+	expr := p.term()
+
+	for p.match(tokens.GREATER, tokens.GREATER_EQUAL, tokens.LESS, tokens.LESS_EQUAL) {
+		op := p.previous()
+		right := p.term()
+		expr = gen.NewBinary(expr, right, op)
+	}
+	return expr
+}
+
+func (p *Parser) term() gen.Expr {
+	expr := p.factor()
+
+	for p.match(tokens.MINUS, tokens.PLUS) {
+		op := p.previous()
+		right := p.factor()
+		expr = gen.NewBinary(expr, right, op)
+	}
+	return expr
+}
+
+func (p *Parser) factor() gen.Expr {
+	expr := p.unary()
+
+	for p.match(tokens.SLASH, tokens.STAR, tokens.PERCENT) {
+		op := p.previous()
+		right := p.unary()
+		expr = gen.NewBinary(expr, right, op)
+	}
+	return expr
+}
+
+func (p *Parser) unary() gen.Expr {
+	if p.match(tokens.BANG, tokens.MINUS) {
+		op := p.previous()
+		right := p.unary()
+		return gen.NewUnary(op, right)
+	}
+	if p.match(tokens.PLUS_PLUS, tokens.MINUS_MINUS) {
+		op := p.previous()
+		target := p.unary()
+		if !isAssignable(target) {
+			p.error(op, "Invalid increment target.")
+			return target
+		}
+		return gen.NewIncrement(target, op, true)
+	}
+	return p.postfix()
+}
+
+func (p *Parser) postfix() gen.Expr {
+	expr := p.call()
+
+	if p.match(tokens.PLUS_PLUS, tokens.MINUS_MINUS) {
+		op := p.previous()
+		if !isAssignable(expr) {
+			p.error(op, "Invalid increment target.")
+			return expr
+		}
+		return gen.NewIncrement(expr, op, false)
+	}
+	return expr
+}
+
+// maxArguments is the most arguments a call may pass, and so the most
+// parameters a function may declare.
+const maxArguments = 255
+
+func (p *Parser) call() gen.Expr {
+	expr := p.primary()
+
+	for {
+		if p.match(tokens.LEFT_PAREN) {
+			expr = p.finishCall(expr)
+		} else if p.match(tokens.DOT) {
+			name := p.consume(tokens.IDENTIFIER, "Expect property name after '.'.")
+			expr = gen.NewGet(expr, name)
+		} else if p.match(tokens.LEFT_BRACKET) {
+			expr = p.finishIndex(expr)
+		} else {
+			break
+		}
+	}
+	return expr
+}
+
+// finishIndex parses an index, a[i], or a slice, a[start:end] with either
+// bound optional, once the '[' has been matched.
+func (p *Parser) finishIndex(object gen.Expr) gen.Expr {
+	var start gen.Expr
+	if !p.check(tokens.COLON) {
+		start = p.expression()
+	}
+	if p.match(tokens.COLON) {
+		var end gen.Expr
+		if !p.check(tokens.RIGHT_BRACKET) {
+			end = p.expression()
+		}
+		bracket := p.consume(tokens.RIGHT_BRACKET, "Expect ']' after slice.")
+		return gen.NewSlice(object, bracket, start, end)
+	}
+	bracket := p.consume(tokens.RIGHT_BRACKET, "Expect ']' after index.")
+	return gen.NewIndex(object, bracket, start)
+}
+
+func (p *Parser) finishCall(callee gen.Expr) gen.Expr {
+	var arguments []gen.Expr
+	if !p.check(tokens.RIGHT_PAREN) {
+		for {
+			if len(arguments) >= maxArguments {
+				p.error(p.peek(), fmt.Sprintf("Can't have more than %d arguments.", maxArguments))
+			}
+			arguments = append(arguments, p.assignment())
+			if !p.match(tokens.COMMA) {
+				break
+			}
+		}
+	}
+	paren := p.consume(tokens.RIGHT_PAREN, "Expect ')' after arguments.")
+	return gen.NewCall(callee, paren, arguments)
+}
+
+func (p *Parser) primary() gen.Expr {
+	if p.match(tokens.FALSE) {
+		return gen.NewLiteral(false, p.previous())
+	}
+	if p.match(tokens.TRUE) {
+		return gen.NewLiteral(true, p.previous())
+	}
+	if p.match(tokens.NIL) {
+		return gen.NewLiteral(nil, p.previous())
+	}
+	if p.match(tokens.NUMBER, tokens.STRING) {
+		return gen.NewLiteral(p.previous().Literal, p.previous())
+	}
+	if p.match(tokens.INTERPOLATION) {
+		return p.interpolation()
+	}
+	if p.match(tokens.THIS) {
+		return gen.NewThis(p.previous())
+	}
+	if p.match(tokens.SUPER) {
+		keyword := p.previous()
+		p.consume(tokens.DOT, "Expect '.' after 'super'.")
+		method := p.consume(tokens.IDENTIFIER, "Expect superclass method name.")
+		return gen.NewSuper(keyword, method)
+	}
+	if p.match(tokens.IDENTIFIER) {
+		return gen.NewVariable(p.previous())
+	}
+	if p.match(tokens.LEFT_PAREN) {
+		expr := p.expression()
 		p.consume(tokens.RIGHT_PAREN, "Expect ')' after expression")
 		return gen.NewGrouping(expr)
 	}
-	error(p.peek().Line, "Expect expression.", "")
-	return nil
+	if p.match(tokens.LEFT_BRACKET) {
+		return p.list()
+	}
+	if p.match(tokens.LEFT_BRACE) {
+		return p.mapLiteral()
+	}
+	if p.match(tokens.MATCH) {
+		return p.matchExpression()
+	}
+	panic(p.error(p.peek(), "Expect expression."))
+}
+
+// list parses a list literal once its '[' has been matched. A trailing comma
+// is allowed.
+func (p *Parser) list() gen.Expr {
+	bracket := p.previous()
+	var elements []gen.Expr
+	for !p.check(tokens.RIGHT_BRACKET) && !p.isAtEnd() {
+		elements = append(elements, p.assignment())
+		if !p.match(tokens.COMMA) {
+			break
+		}
+	}
+	p.consume(tokens.RIGHT_BRACKET, "Expect ']' after list elements.")
+	return gen.NewList(bracket, elements)
+}
+
+// mapLiteral parses a map literal once its '{' has been matched. A '{' only
+// starts a map where an expression is expected; at the start of a statement
+// it opens a block, so a statement beginning with a map literal has to wrap
+// it in parentheses. A trailing comma is allowed.
+func (p *Parser) mapLiteral() gen.Expr {
+	brace := p.previous()
+	var keys, values []gen.Expr
+	for !p.check(tokens.RIGHT_BRACE) && !p.isAtEnd() {
+		keys = append(keys, p.assignment())
+		p.consume(tokens.COLON, "Expect ':' after map key.")
+		values = append(values, p.assignment())
+		if !p.match(tokens.COMMA) {
+			break
+		}
+	}
+	p.consume(tokens.RIGHT_BRACE, "Expect '}' after map entries.")
+	return gen.NewMap(brace, keys, values)
+}
+
+// matchExpression parses a match expression once 'match' has been matched:
+//
+//	match (subject) {
+//	  pattern => result,
+//	  pattern if guard => result,
+//	  ...
+//	}
+func (p *Parser) matchExpression() gen.Expr {
+	keyword := p.previous()
+	p.consume(tokens.LEFT_PAREN, "Expect '(' after 'match'.")
+	subject := p.expression()
+	p.consume(tokens.RIGHT_PAREN, "Expect ')' after match subject.")
+	p.consume(tokens.LEFT_BRACE, "Expect '{' before match arms.")
+
+	var arms []*gen.MatchArm
+	for !p.check(tokens.RIGHT_BRACE) && !p.isAtEnd() {
+		arm := &gen.MatchArm{Pattern: p.pattern()}
+		if p.match(tokens.IF) {
+			arm.Guard = p.assignment()
+		}
+		p.consume(tokens.ARROW, "Expect '=>' after match pattern.")
+		arm.Body = p.assignment()
+		arms = append(arms, arm)
+		if !p.match(tokens.COMMA) {
+			break
+		}
+	}
+	p.consume(tokens.RIGHT_BRACE, "Expect '}' after match arms.")
+	return gen.NewMatch(keyword, subject, arms)
+}
+
+// pattern parses one pattern of a match arm:
+//
+//	_                  anything
+//	name               anything, bound to name
+//	42, "a", true, nil a value equal to the constant
+//	1..10, 1..=10, 5.. a number in the range; '..' leaves out the end
+//	is T, name is T    a value of type T, optionally bound to name; T is
+//	                   a class or one of typeNames, including 'class'
+//	[a, b, ..rest]     a list, element by element
+//	{"key": pattern}   a map with the key, whose value matches pattern
+func (p *Parser) pattern() gen.Pattern {
+	switch {
+	case p.match(tokens.IDENTIFIER):
+		name := p.previous()
+		if name.Lexeme == "_" {
+			name = nil
+		}
+		if p.match(tokens.IS) {
+			return p.typeTest(name)
+		}
+		if name == nil {
+			return gen.NewWildcard(p.previous())
+		}
+		return gen.NewBinding(name)
+	case p.match(tokens.IS):
+		return p.typeTest(nil)
+	case p.match(tokens.LEFT_BRACKET):
+		return p.listPattern()
+	case p.match(tokens.LEFT_BRACE):
+		return p.mapPattern()
+	case p.check(tokens.DOT_DOT), p.check(tokens.DOT_DOT_EQUAL):
+		return p.rangePattern(nil)
+	}
+
+	value, token := p.patternConstant()
+	if p.check(tokens.DOT_DOT) || p.check(tokens.DOT_DOT_EQUAL) {
+		if !isNumber(value) {
+			p.error(token, "Range bounds must be numbers.")
+		}
+		return p.rangePattern(value)
+	}
+	return gen.NewConstant(value, token)
+}
+
+// patternConstant parses a literal in a pattern, which may be a negative
+// number.
+func (p *Parser) patternConstant() (interface{}, *tokens.Token) {
+	if p.match(tokens.MINUS) {
+		number := p.consume(tokens.NUMBER, "Expect number after '-' in pattern.")
+		value, _ := negate(number.Literal)
+		return value, number
+	}
+	switch {
+	case p.match(tokens.NUMBER, tokens.STRING):
+		return p.previous().Literal, p.previous()
+	case p.match(tokens.TRUE):
+		return true, p.previous()
+	case p.match(tokens.FALSE):
+		return false, p.previous()
+	case p.match(tokens.NIL):
+		return nil, p.previous()
+	}
+	panic(p.error(p.peek(), "Expect pattern."))
+}
+
+// rangePattern parses the rest of a range pattern from its '..' or '..='.
+func (p *Parser) rangePattern(low interface{}) gen.Pattern {
+	operator := p.advance()
+	var high interface{}
+	if p.check(tokens.NUMBER) || p.check(tokens.MINUS) {
+		value, token := p.patternConstant()
+		if !isNumber(value) {
+			p.error(token, "Range bounds must be numbers.")
+		}
+		high = value
+	} else if operator.Type == tokens.DOT_DOT_EQUAL {
+		p.error(p.peek(), "Expect upper bound after '..='.")
+	} else if low == nil {
+		p.error(p.peek(), "Expect a bound in range pattern.")
+	}
+	return gen.NewRange(low, high, operator.Type == tokens.DOT_DOT_EQUAL, operator)
+}
+
+// typeNames are the built-in types a type test can name. Any other name in a
+// type test must be a class.
+var typeNames = []string{"number", "int", "decimal", "rational", "float", "string", "bool", "list", "map", "function", "class", "error"}
+
+// typeTest parses the type after 'is'. The type is an identifier, or the
+// keyword 'class' for values that are classes.
+func (p *Parser) typeTest(name *tokens.Token) gen.Pattern {
+	var typeName *tokens.Token
+	if p.match(tokens.CLASS) {
+		typeName = p.previous()
+	} else {
+		typeName = p.consume(tokens.IDENTIFIER, "Expect type name after 'is'.")
+	}
+	var class gen.Expr
+	if !slices.Contains(typeNames, typeName.Lexeme) {
+		class = gen.NewVariable(typeName)
+	}
+	return gen.NewTypeTest(name, typeName, class)
+}
+
+// listPattern parses a list pattern once its '[' has been matched. At most
+// one '..' may appear, optionally followed by a name for the elements it
+// covers.
+func (p *Parser) listPattern() gen.Pattern {
+	bracket := p.previous()
+	var before, after []gen.Pattern
+	var rest *tokens.Token
+	for !p.check(tokens.RIGHT_BRACKET) && !p.isAtEnd() {
+		if p.match(tokens.DOT_DOT) {
+			if rest != nil {
+				p.error(p.previous(), "Can't have more than one '..' in a list pattern.")
+			}
+			rest = p.previous()
+			if p.match(tokens.IDENTIFIER) && p.previous().Lexeme != "_" {
+				rest = p.previous()
+			}
+		} else if rest != nil {
+			after = append(after, p.pattern())
+		} else {
+			before = append(before, p.pattern())
+		}
+		if !p.match(tokens.COMMA) {
+			break
+		}
+	}
+	p.consume(tokens.RIGHT_BRACKET, "Expect ']' after list pattern.")
+	return gen.NewListDestructure(bracket, before, rest, after)
+}
+
+// mapPattern parses a map pattern once its '{' has been matched. Keys are
+// constants.
+func (p *Parser) mapPattern() gen.Pattern {
+	brace := p.previous()
+	var keys []gen.Expr
+	var values []gen.Pattern
+	for !p.check(tokens.RIGHT_BRACE) && !p.isAtEnd() {
+		key, token := p.patternConstant()
+		keys = append(keys, gen.NewLiteral(key, token))
+		p.consume(tokens.COLON, "Expect ':' after map pattern key.")
+		values = append(values, p.pattern())
+		if !p.match(tokens.COMMA) {
+			break
+		}
+	}
+	p.consume(tokens.RIGHT_BRACE, "Expect '}' after map pattern.")
+	return gen.NewMapDestructure(brace, keys, values)
+}
+
+// interpolation parses the rest of a string literal containing "${...}"
+// once its first INTERPOLATION token has been matched.
+func (p *Parser) interpolation() gen.Expr {
+	var parts []gen.Expr
+	for {
+		segment := p.previous()
+		parts = append(parts, gen.NewLiteral(segment.Literal, segment))
+		parts = append(parts, p.expression())
+		if p.match(tokens.INTERPOLATION) {
+			continue
+		}
+		tail := p.consume(tokens.STRING, "Expect end of string interpolation.")
+		parts = append(parts, gen.NewLiteral(tail.Literal, tail))
+		return gen.NewInterpolation(parts)
+	}
+}
+
+// parse parses the program, a list of declarations. A syntax error does not
+// stop it: the parser skips to the next statement boundary and carries on,
+// so every error in the source is reported in one run. The statements that
+// parsed cleanly are returned along with the errors.
+func (p *Parser) parse() ([]gen.Stmt, []*ParseError) {
+	var statements []gen.Stmt
+	for !p.isAtEnd() {
+		if stmt := p.declaration(); stmt != nil {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements, p.errors
+}
+
+// declaration parses a declaration or statement. If it hits a syntax error
+// it returns nil, with the parser moved on to the next statement boundary.
+func (p *Parser) declaration() (stmt gen.Stmt) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*ParseError); !ok {
+				panic(r)
+			}
+			p.synchronize()
+			stmt = nil
+		}
+	}()
+
+	if p.match(tokens.CLASS) {
+		return p.classDeclaration()
+	}
+	if p.match(tokens.FUN) {
+		return p.function("function")
+	}
+	if p.match(tokens.VAR) {
+		return p.varDeclaration()
+	}
+	return p.statement()
+}
+
+func (p *Parser) classDeclaration() gen.Stmt {
+	name := p.consume(tokens.IDENTIFIER, "Expect class name.")
+
+	var superclass *gen.Variable
+	if p.match(tokens.LESS) {
+		p.consume(tokens.IDENTIFIER, "Expect superclass name.")
+		superclass = gen.NewVariable(p.previous())
+	}
+
+	p.consume(tokens.LEFT_BRACE, "Expect '{' before class body.")
+	var methods []*gen.Function
+	for !p.check(tokens.RIGHT_BRACE) && !p.isAtEnd() {
+		methods = append(methods, p.function("method"))
+	}
+	p.consume(tokens.RIGHT_BRACE, "Expect '}' after class body.")
+	return gen.NewClass(name, superclass, methods)
+}
+
+// function parses a function's name, parameters and body once 'fun' has
+// been matched, or a method's in a class body. kind names what is being
+// declared, for error messages.
+func (p *Parser) function(kind string) *gen.Function {
+	name := p.consume(tokens.IDENTIFIER, "Expect "+kind+" name.")
+	p.consume(tokens.LEFT_PAREN, "Expect '(' after "+kind+" name.")
+	var params []*tokens.Token
+	if !p.check(tokens.RIGHT_PAREN) {
+		for {
+			if len(params) >= maxArguments {
+				p.error(p.peek(), fmt.Sprintf("Can't have more than %d parameters.", maxArguments))
+			}
+			params = append(params, p.consume(tokens.IDENTIFIER, "Expect parameter name."))
+			if !p.match(tokens.COMMA) {
+				break
+			}
+		}
+	}
+	p.consume(tokens.RIGHT_PAREN, "Expect ')' after parameters.")
+
+	p.consume(tokens.LEFT_BRACE, "Expect '{' before "+kind+" body.")
+	body := p.block()
+	return gen.NewFunction(name, params, body)
+}
+
+func (p *Parser) varDeclaration() gen.Stmt {
+	name := p.consume(tokens.IDENTIFIER, "Expect variable name.")
+
+	var initializer gen.Expr
+	if p.match(tokens.EQUAL) {
+		initializer = p.expression()
+	}
+	p.consume(tokens.SEMICOLON, "Expect ';' after variable declaration.")
+	return gen.NewVar(name, initializer)
 }
 
-func (p *Parser) parse() gen.Expr {
-    defer func() {
-        if r := recover(); r != nil {
-            fmt.Println("Error during parsing:", r)
-        }
-    }()
-    expr := p.expression()
-    if expr == nil {
-        error(p.peek().Line, "Failed to parse expression.", "")
-        os.Exit(1) // Terminate parsing on error
-    }
-    return expr
+func (p *Parser) statement() gen.Stmt {
+	if p.match(tokens.FOR) {
+		return p.forStatement()
+	}
+	if p.match(tokens.IF) {
+		return p.ifStatement()
+	}
+	if p.match(tokens.PRINT) {
+		return p.printStatement()
+	}
+	if p.match(tokens.RETURN) {
+		return p.returnStatement()
+	}
+	if p.match(tokens.THROW) {
+		return p.throwStatement()
+	}
+	if p.match(tokens.TRY) {
+		return p.tryStatement()
+	}
+	if p.match(tokens.WHILE) {
+		return p.whileStatement()
+	}
+	if p.match(tokens.LEFT_BRACE) {
+		return gen.NewBlock(p.block())
+	}
+	return p.expressionStatement()
+}
+
+// block parses the declarations of a block once its '{' has been matched.
+func (p *Parser) block() []gen.Stmt {
+	var statements []gen.Stmt
+	for !p.check(tokens.RIGHT_BRACE) && !p.isAtEnd() {
+		if stmt := p.declaration(); stmt != nil {
+			statements = append(statements, stmt)
+		}
+	}
+	p.consume(tokens.RIGHT_BRACE, "Expect '}' after block.")
+	return statements
+}
+
+// forStatement parses a C-style for loop and desugars it into a while loop:
+//
+//	for (init; cond; incr) body
+//
+// becomes
+//
+//	{ init; while (cond) { body; incr; } }
+//
+// with a missing condition meaning true.
+func (p *Parser) forStatement() gen.Stmt {
+	keyword := p.previous()
+	p.consume(tokens.LEFT_PAREN, "Expect '(' after 'for'.")
+
+	var initializer gen.Stmt
+	switch {
+	case p.match(tokens.SEMICOLON):
+	case p.match(tokens.VAR):
+		initializer = p.varDeclaration()
+	default:
+		initializer = p.expressionStatement()
+	}
+
+	var condition gen.Expr
+	if !p.check(tokens.SEMICOLON) {
+		condition = p.expression()
+	}
+	p.consume(tokens.SEMICOLON, "Expect ';' after loop condition.")
+
+	var increment gen.Expr
+	if !p.check(tokens.RIGHT_PAREN) {
+		increment = p.expression()
+	}
+	p.consume(tokens.RIGHT_PAREN, "Expect ')' after for clauses.")
+
+	body := p.statement()
+
+	if increment != nil {
+		body = gen.NewBlock([]gen.Stmt{body, gen.NewExpression(increment)})
+	}
+	if condition == nil {
+		condition = gen.NewLiteral(true, keyword)
+	}
+	body = gen.NewWhile(condition, body)
+	if initializer != nil {
+		body = gen.NewBlock([]gen.Stmt{initializer, body})
+	}
+	return body
+}
+
+func (p *Parser) ifStatement() gen.Stmt {
+	p.consume(tokens.LEFT_PAREN, "Expect '(' after 'if'.")
+	condition := p.expression()
+	p.consume(tokens.RIGHT_PAREN, "Expect ')' after if condition.")
+
+	// An else binds to the nearest if before it.
+	thenBranch := p.statement()
+	var elseBranch gen.Stmt
+	if p.match(tokens.ELSE) {
+		elseBranch = p.statement()
+	}
+	return gen.NewIf(condition, thenBranch, elseBranch)
+}
+
+func (p *Parser) returnStatement() gen.Stmt {
+	keyword := p.previous()
+	var value gen.Expr
+	if !p.check(tokens.SEMICOLON) {
+		value = p.expression()
+	}
+	p.consume(tokens.SEMICOLON, "Expect ';' after return value.")
+	return gen.NewReturn(keyword, value)
+}
+
+func (p *Parser) throwStatement() gen.Stmt {
+	keyword := p.previous()
+	value := p.expression()
+	p.consume(tokens.SEMICOLON, "Expect ';' after thrown value.")
+	return gen.NewThrow(keyword, value)
+}
+
+// tryStatement parses
+//
+//	try { ... } catch (name) { ... } finally { ... }
+//
+// once 'try' has been matched. Either clause may be left out, but not both,
+// and the catch clause may leave out its name.
+func (p *Parser) tryStatement() gen.Stmt {
+	keyword := p.previous()
+	p.consume(tokens.LEFT_BRACE, "Expect '{' after 'try'.")
+	body := p.block()
+
+	var catchName *tokens.Token
+	var catchBody, finallyBody []gen.Stmt
+	hasCatch := p.match(tokens.CATCH)
+	if hasCatch {
+		if p.match(tokens.LEFT_PAREN) {
+			catchName = p.consume(tokens.IDENTIFIER, "Expect error name after '('.")
+			p.consume(tokens.RIGHT_PAREN, "Expect ')' after error name.")
+		}
+		p.consume(tokens.LEFT_BRACE, "Expect '{' after catch clause.")
+		// An empty catch block still catches, so it must not be nil.
+		catchBody = append([]gen.Stmt{}, p.block()...)
+	}
+	if p.match(tokens.FINALLY) {
+		p.consume(tokens.LEFT_BRACE, "Expect '{' after 'finally'.")
+		finallyBody = append([]gen.Stmt{}, p.block()...)
+	} else if !hasCatch {
+		p.error(p.peek(), "Expect 'catch' or 'finally' after try block.")
+	}
+	return gen.NewTry(keyword, body, catchName, catchBody, finallyBody)
+}
+
+func (p *Parser) whileStatement() gen.Stmt {
+	p.consume(tokens.LEFT_PAREN, "Expect '(' after 'while'.")
+	condition := p.expression()
+	p.consume(tokens.RIGHT_PAREN, "Expect ')' after condition.")
+	body := p.statement()
+	return gen.NewWhile(condition, body)
+}
+
+func (p *Parser) printStatement() gen.Stmt {
+	value := p.expression()
+	p.consume(tokens.SEMICOLON, "Expect ';' after value.")
+	return gen.NewPrint(value)
+}
+
+func (p *Parser) expressionStatement() gen.Stmt {
+	expr := p.expression()
+	p.consume(tokens.SEMICOLON, "Expect ';' after expression.")
+	return gen.NewExpression(expr)
+}
+
+// ParseError is a syntax error. Grammar rules panic with one to unwind to
+// the nearest point that can recover.
+type ParseError struct {
+	Token   *tokens.Token
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("[line %d:%d] %s", e.Token.Line, e.Token.Column, e.Message)
+}
+
+// error reports a syntax error at token and returns it for the caller to
+// panic with.
+func (p *Parser) error(token *tokens.Token, message string) *ParseError {
+	err := &ParseError{Token: token, Message: message}
+	p.errors = append(p.errors, err)
+
+	where := " at '" + token.Lexeme + "'"
+	if token.Type == tokens.EOF {
+		where = " at end"
+	}
+	report(token.Span(), where, message, "")
+	return err
 }
 
 func (p *Parser) synchronize() {
@@ -469,7 +2066,7 @@ func (p *Parser) synchronize() {
 		}
 
 		switch p.peek().Type {
-		case tokens.CLASS, tokens.FUN, tokens.VAR, tokens.FOR, tokens.IF, tokens.WHILE, tokens.PRINT, tokens.RETURN:
+		case tokens.CLASS, tokens.FUN, tokens.VAR, tokens.FOR, tokens.IF, tokens.WHILE, tokens.PRINT, tokens.RETURN, tokens.THROW, tokens.TRY:
 			return
 		}
 		p.advance()
@@ -495,7 +2092,8 @@ func (p *Parser) check(t tokens.TokenType) bool {
 
 func (p *Parser) advance() *tokens.Token {
 	if !p.isAtEnd() {
-		p.current++
+		p.prev = p.lookahead
+		p.lookahead = p.next()
 	}
 	return p.previous()
 }
@@ -505,31 +2103,481 @@ func (p *Parser) isAtEnd() bool {
 }
 
 func (p *Parser) peek() *tokens.Token {
-	return p.tokens[p.current]
+	return p.lookahead
 }
 
 func (p *Parser) previous() *tokens.Token {
-	return p.tokens[p.current-1]
+	return p.prev
 }
 
 func (p *Parser) consume(tokenType tokens.TokenType, message string) *tokens.Token {
 	if p.check(tokenType) {
 		return p.advance()
 	}
-	return nil
-	//panic(p.error(p.peek(), message))
+	panic(p.error(p.peek(), message))
 }
 
-type Interpreter struct{}
+type Interpreter struct {
+	numbers numberMode
+	globals *Environment
+
+	// environment is the innermost scope of the code being run.
+	environment *Environment
+
+	// locals holds, for each local variable reference, where the variable
+	// lives, as worked out by the Resolver. References missing from it are
+	// globals.
+	locals map[gen.Expr]localSlot
+
+	// depth is how many calls to functions declared in the program are
+	// running, to catch runaway recursion before Go's stack overflows.
+	depth int
+}
 
 func NewInterpreter() *Interpreter {
-	return &Interpreter{}
+	globals := NewEnvironment(nil)
+	defineNatives(globals)
+	return &Interpreter{
+		numbers:     defaultNumberMode(),
+		globals:     globals,
+		environment: globals,
+		locals:      make(map[gen.Expr]localSlot),
+	}
+}
+
+// localSlot is where a local variable lives: depth scopes out from where it
+// is used, in the given slot of that scope.
+type localSlot struct {
+	depth int
+	slot  int
+}
+
+// resolve records that the variable expr refers to lives depth scopes out
+// from where it is used, in the given slot.
+func (i *Interpreter) resolve(expr gen.Expr, depth, slot int) {
+	i.locals[expr] = localSlot{depth: depth, slot: slot}
+}
+
+// lookUpVariable returns the value of the variable name used by expr.
+func (i *Interpreter) lookUpVariable(name *tokens.Token, expr gen.Expr) interface{} {
+	if local, ok := i.locals[expr]; ok {
+		return i.environment.GetAt(local.depth, local.slot)
+	}
+	value, ok := i.globals.Get(name.Lexeme)
+	if !ok {
+		i.runtimeError(NameError, name, "Undefined variable '"+name.Lexeme+"'.")
+	}
+	return value
+}
+
+// runtimeError throws an error of the given kind found while running the
+// program at the token it concerns.
+func (i *Interpreter) runtimeError(kind ErrorKind, token *tokens.Token, message string) {
+	i.runtimeErrorAt(kind, token.Span(), message)
+}
+
+// runtimeErrorAt is runtimeError for an error that concerns a whole
+// expression, such as an operation on operands of the wrong type.
+func (i *Interpreter) runtimeErrorAt(kind ErrorKind, span tokens.Span, message string) {
+	i.throw(NewRuntimeError(kind, message), span)
+}
+
+// Interpret runs the statements of a program in order. A value thrown and
+// not caught is reported and stops the program.
+func (i *Interpreter) Interpret(statements []gen.Stmt) {
+	thrown := i.catch(func() {
+		for _, stmt := range statements {
+			i.execute(stmt)
+		}
+	})
+	if thrown != nil {
+		i.reportUncaught(thrown)
+		os.Exit(70)
+	}
+}
+
+func (i *Interpreter) execute(stmt gen.Stmt) {
+	stmt.Accept(i)
 }
 
 func (i *Interpreter) Evaluate(expr gen.Expr) interface{} {
 	return expr.Accept(i)
 }
 
+func (i *Interpreter) VisitExpressionStmt(stmt *gen.Expression) interface{} {
+	i.Evaluate(stmt.Expression)
+	return nil
+}
+
+func (i *Interpreter) VisitPrintStmt(stmt *gen.Print) interface{} {
+	value := i.Evaluate(stmt.Expression)
+	fmt.Println(i.stringify(value))
+	return nil
+}
+
+func (i *Interpreter) VisitVarStmt(stmt *gen.Var) interface{} {
+	var value interface{}
+	if stmt.Initializer != nil {
+		value = i.Evaluate(stmt.Initializer)
+	}
+	i.environment.Define(stmt.Name.Lexeme, value)
+	return nil
+}
+
+func (i *Interpreter) VisitIfStmt(stmt *gen.If) interface{} {
+	if i.isTruthy(i.Evaluate(stmt.Condition)) {
+		i.execute(stmt.ThenBranch)
+	} else if stmt.ElseBranch != nil {
+		i.execute(stmt.ElseBranch)
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitWhileStmt(stmt *gen.While) interface{} {
+	for i.isTruthy(i.Evaluate(stmt.Condition)) {
+		i.execute(stmt.Body)
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitClassStmt(stmt *gen.Class) interface{} {
+	var superclass *Class
+	if stmt.Superclass != nil {
+		class, ok := i.Evaluate(stmt.Superclass).(*Class)
+		if !ok {
+			i.runtimeError(TypeError, stmt.Superclass.Name, "Superclass must be a class.")
+		}
+		superclass = class
+	}
+
+	// Methods close over a scope holding 'super' when the class inherits.
+	environment := i.environment
+	if superclass != nil {
+		environment = NewEnvironment(environment)
+		environment.Define("super", superclass)
+	}
+
+	methods := make(map[string]*Function)
+	for _, method := range stmt.Methods {
+		methods[method.Name.Lexeme] = NewFunction(method, environment, method.Name.Lexeme == "init")
+	}
+
+	class := NewClass(stmt.Name.Lexeme, superclass, methods)
+	i.environment.Define(stmt.Name.Lexeme, class)
+	return nil
+}
+
+func (i *Interpreter) VisitFunctionStmt(stmt *gen.Function) interface{} {
+	function := NewFunction(stmt, i.environment, false)
+	i.environment.Define(stmt.Name.Lexeme, function)
+	return nil
+}
+
+func (i *Interpreter) VisitReturnStmt(stmt *gen.Return) interface{} {
+	var value interface{}
+	if stmt.Value != nil {
+		value = i.Evaluate(stmt.Value)
+	}
+	panic(&returnValue{value: value})
+}
+
+func (i *Interpreter) VisitBlockStmt(stmt *gen.Block) interface{} {
+	i.executeBlock(stmt.Statements, NewEnvironment(i.environment))
+	return nil
+}
+
+// executeBlock runs statements in environment, restoring the current
+// environment afterwards however the block is left.
+func (i *Interpreter) executeBlock(statements []gen.Stmt, environment *Environment) {
+	previous := i.environment
+	defer func() { i.environment = previous }()
+
+	i.environment = environment
+	for _, stmt := range statements {
+		i.execute(stmt)
+	}
+}
+
+func (i *Interpreter) VisitVariableExpr(expr *gen.Variable) interface{} {
+	return i.lookUpVariable(expr.Name, expr)
+}
+
+// VisitLogicalExpr returns whichever operand decided the result rather than
+// a bool, so "nil or 2" is 2.
+func (i *Interpreter) VisitLogicalExpr(expr *gen.Logical) interface{} {
+	left := i.Evaluate(expr.Left)
+
+	if expr.Operator.Type == tokens.OR {
+		if i.isTruthy(left) {
+			return left
+		}
+	} else if !i.isTruthy(left) {
+		return left
+	}
+	return i.Evaluate(expr.Right)
+}
+
+func (i *Interpreter) VisitCallExpr(expr *gen.Call) interface{} {
+	callee := i.Evaluate(expr.Callee)
+
+	arguments := make([]interface{}, len(expr.Arguments))
+	for n, argument := range expr.Arguments {
+		arguments[n] = i.Evaluate(argument)
+	}
+
+	function, ok := callee.(Callable)
+	if !ok {
+		i.runtimeError(TypeError, expr.Paren, "Can only call functions and classes.")
+	}
+	if len(arguments) != function.Arity() {
+		i.runtimeError(TypeError, expr.Paren, fmt.Sprintf("Expected %d arguments but got %d.", function.Arity(), len(arguments)))
+	}
+	result, failure := function.Call(i, arguments)
+	if failure != nil {
+		i.throw(failure, expr.Paren.Span())
+	}
+	return result
+}
+
+func (i *Interpreter) VisitConditionalExpr(expr *gen.Conditional) interface{} {
+	if i.isTruthy(i.Evaluate(expr.Condition)) {
+		return i.Evaluate(expr.ThenBranch)
+	}
+	return i.Evaluate(expr.ElseBranch)
+}
+
+func (i *Interpreter) VisitCommaExpr(expr *gen.Comma) interface{} {
+	i.Evaluate(expr.Left)
+	return i.Evaluate(expr.Right)
+}
+
+// propertyHolder is a value that has properties: an instance's fields and
+// methods, the built-in methods of a list or map, or the details of an error.
+type propertyHolder interface {
+	Get(name string) (interface{}, bool)
+}
+
+func (i *Interpreter) VisitGetExpr(expr *gen.Get) interface{} {
+	object, ok := i.Evaluate(expr.Object).(propertyHolder)
+	if !ok {
+		i.runtimeError(TypeError, expr.Name, "Only instances, lists, maps and errors have properties.")
+	}
+	value, ok := object.Get(expr.Name.Lexeme)
+	if !ok {
+		i.runtimeError(PropertyError, expr.Name, "Undefined property '"+expr.Name.Lexeme+"'.")
+	}
+	return value
+}
+
+func (i *Interpreter) VisitListExpr(expr *gen.List) interface{} {
+	elements := make([]interface{}, len(expr.Elements))
+	for n, element := range expr.Elements {
+		elements[n] = i.Evaluate(element)
+	}
+	return NewList(elements)
+}
+
+func (i *Interpreter) VisitMapExpr(expr *gen.Map) interface{} {
+	m := NewMap()
+	for n := range expr.Keys {
+		key := i.Evaluate(expr.Keys[n])
+		value := i.Evaluate(expr.Values[n])
+		if message := m.Put(key, value); message != "" {
+			i.runtimeError(TypeError, expr.Brace, message)
+		}
+	}
+	return m
+}
+
+func (i *Interpreter) VisitIndexExpr(expr *gen.Index) interface{} {
+	return i.indexGet(i.Evaluate(expr.Object), i.Evaluate(expr.Index), expr.Bracket)
+}
+
+func (i *Interpreter) VisitSliceExpr(expr *gen.Slice) interface{} {
+	list, ok := i.Evaluate(expr.Object).(*List)
+	if !ok {
+		i.runtimeError(TypeError, expr.Bracket, "Only lists can be sliced.")
+	}
+	var start, end interface{}
+	if expr.Start != nil {
+		start = i.Evaluate(expr.Start)
+	}
+	if expr.End != nil {
+		end = i.Evaluate(expr.End)
+	}
+	slice, message := list.Slice(start, end)
+	if message != "" {
+		i.runtimeError(TypeError, expr.Bracket, message)
+	}
+	return slice
+}
+
+func (i *Interpreter) VisitSetIndexExpr(expr *gen.SetIndex) interface{} {
+	object := i.Evaluate(expr.Object)
+	index := i.Evaluate(expr.Index)
+	value := i.Evaluate(expr.Value)
+	i.indexSet(object, index, value, expr.Bracket)
+	return value
+}
+
+// indexGet returns object[index] for a list or map, reporting errors at
+// bracket. Reading a key a map does not have is an error; the map's get
+// method takes a fallback instead.
+func (i *Interpreter) indexGet(object, index interface{}, bracket *tokens.Token) interface{} {
+	switch o := object.(type) {
+	case *List:
+		position, message := o.index(index)
+		if message != "" {
+			i.runtimeError(indexErrorKind(index), bracket, message)
+		}
+		return o.elements[position]
+	case *Map:
+		value, found, message := o.Lookup(index)
+		if message != "" {
+			i.runtimeError(TypeError, bracket, message)
+		}
+		if !found {
+			i.runtimeError(KeyError, bracket, "Key "+i.stringifyElement(index, make(map[interface{}]bool))+" not found.")
+		}
+		return value
+	}
+	i.runtimeError(TypeError, bracket, "Only lists and maps can be indexed.")
+	return nil
+}
+
+// indexErrorKind is the kind of error for a bad list index: an integer
+// outside the list, or something that is not an integer at all.
+func indexErrorKind(index interface{}) ErrorKind {
+	if rankOf(index) == rankInt {
+		return IndexError
+	}
+	return TypeError
+}
+
+// indexSet stores object[index] = value for a list or map, reporting errors
+// at bracket. Storing into a map adds the key if it is missing.
+func (i *Interpreter) indexSet(object, index, value interface{}, bracket *tokens.Token) {
+	switch o := object.(type) {
+	case *List:
+		position, message := o.index(index)
+		if message != "" {
+			i.runtimeError(indexErrorKind(index), bracket, message)
+		}
+		o.elements[position] = value
+	case *Map:
+		if message := o.Put(index, value); message != "" {
+			i.runtimeError(TypeError, bracket, message)
+		}
+	default:
+		i.runtimeError(TypeError, bracket, "Only lists and maps can be indexed.")
+	}
+}
+
+func (i *Interpreter) VisitSetExpr(expr *gen.Set) interface{} {
+	instance, ok := i.Evaluate(expr.Object).(*Instance)
+	if !ok {
+		i.runtimeError(TypeError, expr.Name, "Only instances have fields.")
+	}
+	value := i.Evaluate(expr.Value)
+	instance.Set(expr.Name.Lexeme, value)
+	return value
+}
+
+func (i *Interpreter) VisitThisExpr(expr *gen.This) interface{} {
+	return i.lookUpVariable(expr.Keyword, expr)
+}
+
+// VisitSuperExpr looks the method up starting at the superclass of the class
+// the method using super was declared in, and binds it to the current
+// instance.
+func (i *Interpreter) VisitSuperExpr(expr *gen.Super) interface{} {
+	// The scope binding 'this' is always just inside the one holding 'super',
+	// and each holds nothing else.
+	distance := i.locals[expr].depth
+	superclass := i.environment.GetAt(distance, 0).(*Class)
+	object := i.environment.GetAt(distance-1, 0)
+
+	method := superclass.findMethod(expr.Method.Lexeme)
+	if method == nil {
+		i.runtimeError(PropertyError, expr.Method, "Undefined property '"+expr.Method.Lexeme+"'.")
+	}
+	return method.bind(object.(*Instance))
+}
+
+func (i *Interpreter) VisitAssignExpr(expr *gen.Assign) interface{} {
+	value := i.Evaluate(expr.Value)
+	i.assignVariable(expr.Name, expr, value)
+	return value
+}
+
+// assignVariable sets the variable name used by expr.
+func (i *Interpreter) assignVariable(name *tokens.Token, expr gen.Expr, value interface{}) {
+	if local, ok := i.locals[expr]; ok {
+		i.environment.AssignAt(local.depth, local.slot, value)
+	} else if !i.globals.Assign(name.Lexeme, value) {
+		i.runtimeError(NameError, name, "Undefined variable '"+name.Lexeme+"'.")
+	}
+}
+
+// compoundOperators maps each compound assignment and increment operator to
+// the arithmetic it performs.
+var compoundOperators = map[tokens.TokenType]tokens.TokenType{
+	tokens.PLUS_EQUAL:    tokens.PLUS,
+	tokens.MINUS_EQUAL:   tokens.MINUS,
+	tokens.STAR_EQUAL:    tokens.STAR,
+	tokens.SLASH_EQUAL:   tokens.SLASH,
+	tokens.PERCENT_EQUAL: tokens.PERCENT,
+	tokens.PLUS_PLUS:     tokens.PLUS,
+	tokens.MINUS_MINUS:   tokens.MINUS,
+}
+
+func (i *Interpreter) VisitCompoundExpr(expr *gen.Compound) interface{} {
+	_, result := i.update(expr.Target, func(old interface{}) interface{} {
+		return i.arithmetic(gen.SpanOf(expr), compoundOperators[expr.Operator.Type], old, i.Evaluate(expr.Value))
+	})
+	return result
+}
+
+// VisitIncrementExpr returns the new value for ++x and the old one for x++.
+func (i *Interpreter) VisitIncrementExpr(expr *gen.Increment) interface{} {
+	old, result := i.update(expr.Target, func(old interface{}) interface{} {
+		return i.arithmetic(gen.SpanOf(expr), compoundOperators[expr.Operator.Type], old, int64(1))
+	})
+	if expr.Prefix {
+		return result
+	}
+	return old
+}
+
+// update reads the variable, field or element target, stores compute's
+// result back into it and returns both values. Any object the target belongs
+// to is evaluated once, so obj().count += 1 calls obj only once.
+func (i *Interpreter) update(target gen.Expr, compute func(old interface{}) interface{}) (old, result interface{}) {
+	switch t := target.(type) {
+	case *gen.Variable:
+		old = i.lookUpVariable(t.Name, t)
+		result = compute(old)
+		i.assignVariable(t.Name, t, result)
+	case *gen.Get:
+		instance, ok := i.Evaluate(t.Object).(*Instance)
+		if !ok {
+			i.runtimeError(TypeError, t.Name, "Only instances have fields.")
+		}
+		old, ok = instance.Get(t.Name.Lexeme)
+		if !ok {
+			i.runtimeError(PropertyError, t.Name, "Undefined property '"+t.Name.Lexeme+"'.")
+		}
+		result = compute(old)
+		instance.Set(t.Name.Lexeme, result)
+	case *gen.Index:
+		object, index := i.Evaluate(t.Object), i.Evaluate(t.Index)
+		old = i.indexGet(object, index, t.Bracket)
+		result = compute(old)
+		i.indexSet(object, index, result, t.Bracket)
+	}
+	return old, result
+}
+
 func (i *Interpreter) VisitLiteralExpr(expr *gen.Literal) interface{} {
 	return expr.Value
 }
@@ -538,16 +2586,24 @@ func (i *Interpreter) VisitGroupingExpr(expr *gen.Grouping) interface{} {
 	return i.Evaluate(expr.Expression)
 }
 
+func (i *Interpreter) VisitInterpolationExpr(expr *gen.Interpolation) interface{} {
+	var builder strings.Builder
+	for _, part := range expr.Parts {
+		builder.WriteString(i.stringify(i.Evaluate(part)))
+	}
+	return builder.String()
+}
+
 func (i *Interpreter) VisitUnaryExpr(expr *gen.Unary) interface{} {
     right := i.Evaluate(expr.Right)
 
     switch expr.Operator.Type {
     case tokens.MINUS:
-        if num, ok := right.(float64); ok {
-            return -num
+        result, message := negate(right)
+        if message != "" {
+            i.runtimeErrorAt(TypeError, gen.SpanOf(expr), message)
         }
-        fmt.Println("Operand must be a number.")
-        os.Exit(1)
+        return result
     case tokens.BANG:
         return !i.isTruthy(right)
     }
@@ -561,57 +2617,15 @@ func (i *Interpreter) VisitBinaryExpr(expr *gen.Binary) interface{} {
     right := i.Evaluate(expr.Right)
 
     switch expr.Operator.Type {
-    case tokens.PLUS:
-        switch l := left.(type) {
-        case float64:
-            if r, ok := right.(float64); ok {
-                return l + r
-            }
-        case string:
-            if r, ok := right.(string); ok {
-                return l + r
-            }
-        }
-        fmt.Println("Operands must be two numbers or two strings.")
-        os.Exit(1)
-
-    case tokens.MINUS, tokens.STAR, tokens.SLASH:
-        l, lok := left.(float64)
-        r, rok := right.(float64)
-        if !lok || !rok {
-            fmt.Println("Operands must be numbers.")
-            os.Exit(1)
-        }
-        switch expr.Operator.Type {
-        case tokens.MINUS:
-            return l - r
-        case tokens.STAR:
-            return l * r
-        case tokens.SLASH:
-            if r == 0 {
-                fmt.Println("Division by zero.")
-                os.Exit(1)
-            }
-            return l / r
-        }
+    case tokens.PLUS, tokens.MINUS, tokens.STAR, tokens.SLASH, tokens.PERCENT:
+        return i.arithmetic(gen.SpanOf(expr), expr.Operator.Type, left, right)
 
     case tokens.GREATER, tokens.GREATER_EQUAL, tokens.LESS, tokens.LESS_EQUAL:
-        l, lok := left.(float64)
-        r, rok := right.(float64)
-        if !lok || !rok {
-            fmt.Println("Operands must be numbers.")
-            os.Exit(1)
-        }
-        switch expr.Operator.Type {
-        case tokens.GREATER:
-            return l > r
-        case tokens.GREATER_EQUAL:
-            return l >= r
-        case tokens.LESS:
-            return l < r
-        case tokens.LESS_EQUAL:
-            return l <= r
+        result, message := compareNumbers(expr.Operator.Type, left, right)
+        if message != "" {
+            i.runtimeErrorAt(TypeError, gen.SpanOf(expr), message)
         }
+        return result
 
     case tokens.EQUAL_EQUAL:
         return i.isEqual(left, right)
@@ -622,6 +2636,53 @@ func (i *Interpreter) VisitBinaryExpr(expr *gen.Binary) interface{} {
     // Unreachable
     return nil
 }
+// arithmetic applies one of + - * / % to two values, with + also joining
+// two strings. Errors are reported at span, the whole operation.
+func (i *Interpreter) arithmetic(span tokens.Span, op tokens.TokenType, left, right interface{}) interface{} {
+	if op == tokens.PLUS {
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r
+			}
+		}
+		if !isNumber(left) || !isNumber(right) {
+			i.runtimeErrorAt(TypeError, span, "Operands must be two numbers or two strings.")
+		}
+	}
+	result, message := i.numbers.arithmetic(op, left, right)
+	if message != "" {
+		// Numbers that can be combined only fail on values such as a zero
+		// divisor; anything else is the wrong type of operand.
+		kind := TypeError
+		if isNumber(left) && isNumber(right) && !mixesDecimalAndFloat(left, right) {
+			kind = ArithmeticError
+		}
+		i.runtimeErrorAt(kind, span, message)
+	}
+	return result
+}
+
+// stringify converts a runtime value to the text used when printing it or
+// splicing it into a string.
+func (i *Interpreter) stringify(object interface{}) string {
+	if object == nil {
+		return "nil"
+	}
+	if s, ok := object.(string); ok {
+		return s
+	}
+	if isNumber(object) {
+		return formatNumber(object)
+	}
+	if list, ok := object.(*List); ok {
+		return i.stringifyList(list, make(map[interface{}]bool))
+	}
+	if m, ok := object.(*Map); ok {
+		return i.stringifyMap(m, make(map[interface{}]bool))
+	}
+	return fmt.Sprintf("%v", object)
+}
+
 func (i *Interpreter) isTruthy(object interface{}) bool {
 	if object == nil {
 		return false
@@ -639,69 +2700,103 @@ func (i *Interpreter) isEqual(a, b interface{}) bool {
 	if a == nil {
 		return false
 	}
+	if isNumber(a) && isNumber(b) {
+		return numbersEqual(a, b)
+	}
 	return a == b
 }
 
 func main() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: ./your_program.sh <command> <source-file>")
+		fmt.Println("Usage: ./your_program.sh <command> [flags] <source-file>")
 		os.Exit(1)
 	}
 
 	command := os.Args[1]
-	filename := os.Args[2]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	exact := flags.Bool("exact", false, "make division of integers produce exact rationals")
+	decimalScale := flags.Int("decimal-scale", defaultNumberMode().decimalScale, "fractional digits kept by decimal products and quotients")
+	rounding := flags.String("rounding", string(defaultNumberMode().rounding), "rounding mode for decimals: half-even, half-up, half-down, up, down, ceiling or floor")
+	format := flags.String("format", formatText, "tokenize output: text, jsonl or binary")
+	trivia := flags.Bool("trivia", false, "tokenize keeps whitespace and comments as token trivia")
+	// Flags may come before or after the source file. flag stops at the
+	// first argument that is not a flag, so the rest is parsed again after
+	// the file name; anything else left over is a mistake.
+	flags.Parse(os.Args[2:])
+	if flags.NArg() < 1 {
+		fmt.Println("Usage: ./your_program.sh <command> [flags] <source-file>")
+		os.Exit(1)
+	}
+	filename := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+	if flags.NArg() > 0 {
+		fmt.Println("Usage: ./your_program.sh <command> [flags] <source-file>")
+		os.Exit(1)
+	}
+	if !slices.Contains(roundingModes, roundingMode(*rounding)) || *decimalScale < 0 || !slices.Contains(tokenFormats, *format) {
+		flags.Usage()
+		os.Exit(1)
+	}
 
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Could not read file: %s\n", err)
-		os.Exit(65)
+	// "-" reads the program from standard input. Either way the source is
+	// streamed through the scanner rather than read up front.
+	var input io.Reader = os.Stdin
+	if filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not read file: %s\n", err)
+			os.Exit(65)
+		}
+		defer file.Close()
+		input = file
 	}
+	scanner := NewReaderScanner(input)
+	source = scanner
 
-	source := string(data)
 	switch command {
 	case "tokenize":
-		scanner := NewScanner(source)
-		tokens := scanner.ScanTokens()
-		for _, token := range tokens {
-			fmt.Println(token)
+		scanner.PreserveTrivia = *trivia
+		output := bufio.NewWriter(os.Stdout)
+		for {
+			token := scanner.Next()
+			writeToken(output, *format, token)
+			if token.Type == tokens.EOF {
+				break
+			}
+		}
+		output.Flush()
+		reportDiagnostics(scanner.Diagnostics)
+		if hadError {
+			os.Exit(1)
 		}
 
 	case "parse":
-    scanner := NewScanner(source)
-    tokens := scanner.ScanTokens()
-    if hadError {
-        os.Exit(1) // Stop if scanning failed
+    scanner.KeepLines = true
+    parser := NewParser(scanner)
+    statements, parseErrors := parser.parse()
+    if len(parseErrors) > 0 || len(scanner.Diagnostics) > 0 {
+        os.Exit(1) // Stop if scanning or parsing failed
     }
-    parser := NewParser(tokens)
-    expr := parser.parse()
-    if expr != nil {
-        printer := &ASTPrinter{}
-        switch e := expr.(type) {
-        case *gen.Binary:
-            fmt.Println(printer.VisitBinaryExpr(e))
-        case *gen.Grouping:
-            fmt.Println(printer.VisitGroupingExpr(e))
-        case *gen.Unary:
-            fmt.Println(printer.VisitUnaryExpr(e))
-        case *gen.Literal:
-            fmt.Println(printer.VisitLiteralExpr(e))
-        default:
-            fmt.Println("Unknown expression type.")
-        }
-    } else {
-        fmt.Println("Parsing failed.")
+    printer := &ASTPrinter{}
+    for _, stmt := range statements {
+        fmt.Println(stmt.Accept(printer))
     }
 	case "interp":
-		scanner := NewScanner(source)
-		tokens := scanner.ScanTokens()
-		if hadError {
-			os.Exit(1) // Stop if scanning failed
+		scanner.KeepLines = true
+		parser := NewParser(scanner)
+		statements, parseErrors := parser.parse()
+		if len(parseErrors) > 0 || len(scanner.Diagnostics) > 0 {
+			os.Exit(1) // Stop if scanning or parsing failed
 		}
-		parser := NewParser(tokens)
-		expr := parser.parse()
 		interpreter := NewInterpreter()
-		result := interpreter.Evaluate(expr)
-		fmt.Println("Result:", result)	
+		NewResolver(interpreter).resolve(statements)
+		if hadError {
+			os.Exit(1)
+		}
+		interpreter.numbers.exactDivision = *exact
+		interpreter.numbers.decimalScale = *decimalScale
+		interpreter.numbers.rounding = roundingMode(*rounding)
+		interpreter.Interpret(statements)
 
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Map is a map value, written {key: value, ...}. Like lists, maps are shared
+// rather than copied, and two maps are equal only if they are the same map.
+// Keys keep the order they were first inserted in.
+//
+// Only numbers, strings, booleans and nil can be keys; they cannot change,
+// so a key never moves once stored. Two keys are the same key when they are
+// == to each other, so 1, 1.0 and 2/2 all find the same entry. NaN is not
+// equal to itself, so it cannot be a key.
+type Map struct {
+	// buckets groups the entries by hashKey. Keys with the same hash are
+	// still compared with keysEqual, since 1d and 1.0 hash alike but are not
+	// equal.
+	buckets map[interface{}][]*mapEntry
+	order   []*mapEntry
+}
+
+type mapEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+func NewMap() *Map {
+	return &Map{buckets: make(map[interface{}][]*mapEntry)}
+}
+
+// numberKey is the hash of a number: its exact value as a fraction.
+type numberKey string
+
+// hashKey returns a Go map key for value that is the same for any two values
+// that are ==. ok is false if value cannot be a map key.
+func hashKey(value interface{}) (key interface{}, ok bool) {
+	switch v := value.(type) {
+	case nil, bool, string:
+		return v, true
+	case int64:
+		return numberKey(strconv.FormatInt(v, 10)), true
+	case float64:
+		if math.IsNaN(v) {
+			return nil, false
+		}
+		if math.IsInf(v, 0) {
+			return v, true
+		}
+		return numberKey(new(big.Rat).SetFloat64(v).RatString()), true
+	}
+	if isNumber(value) {
+		return numberKey(toRat(value).RatString()), true
+	}
+	return nil, false
+}
+
+// keysEqual is == for keys.
+func keysEqual(a, b interface{}) bool {
+	if isNumber(a) && isNumber(b) {
+		return numbersEqual(a, b)
+	}
+	return a == b
+}
+
+const unhashableKey = "Only numbers, strings, booleans and nil can be map keys."
+
+// entry returns the entry for key, or nil if there is none. On failure
+// message describes the runtime error.
+func (m *Map) entry(key interface{}) (*mapEntry, string) {
+	hash, ok := hashKey(key)
+	if !ok {
+		return nil, unhashableKey
+	}
+	for _, entry := range m.buckets[hash] {
+		if keysEqual(entry.key, key) {
+			return entry, ""
+		}
+	}
+	return nil, ""
+}
+
+// Lookup returns the value stored under key.
+func (m *Map) Lookup(key interface{}) (value interface{}, found bool, message string) {
+	entry, message := m.entry(key)
+	if entry == nil {
+		return nil, false, message
+	}
+	return entry.value, true, ""
+}
+
+// Put stores value under key, replacing any value already there.
+func (m *Map) Put(key, value interface{}) string {
+	entry, message := m.entry(key)
+	if message != "" {
+		return message
+	}
+	if entry != nil {
+		entry.value = value
+		return ""
+	}
+	hash, _ := hashKey(key)
+	entry = &mapEntry{key: key, value: value}
+	m.buckets[hash] = append(m.buckets[hash], entry)
+	m.order = append(m.order, entry)
+	return ""
+}
+
+// Remove deletes key and returns the value it had.
+func (m *Map) Remove(key interface{}) (value interface{}, found bool, message string) {
+	entry, message := m.entry(key)
+	if entry == nil {
+		return nil, false, message
+	}
+	hash, _ := hashKey(key)
+	m.buckets[hash] = removeEntry(m.buckets[hash], entry)
+	if len(m.buckets[hash]) == 0 {
+		delete(m.buckets, hash)
+	}
+	m.order = removeEntry(m.order, entry)
+	return entry.value, true, ""
+}
+
+func removeEntry(entries []*mapEntry, entry *mapEntry) []*mapEntry {
+	for n, e := range entries {
+		if e == entry {
+			return append(entries[:n:n], entries[n+1:]...)
+		}
+	}
+	return entries
+}
+
+// Get returns the built-in method called name, bound to the map:
+//
+//	get(key, fallback)  the value stored under key, or fallback
+//	has(key)            whether key is in the map
+//	remove(key)         deletes key and returns its value, or nil
+//	keys()              a list of the keys, in insertion order
+//	values()            a list of the values, in the same order
+//	len()               the number of entries
+func (m *Map) Get(name string) (interface{}, bool) {
+	switch name {
+	case "get":
+		return &nativeFunction{arity: 2, fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			value, found, message := m.Lookup(arguments[0])
+			if message != "" {
+				return nil, NewRuntimeError(TypeError, message)
+			}
+			if !found {
+				return arguments[1], nil
+			}
+			return value, nil
+		}}, true
+	case "has":
+		return &nativeFunction{arity: 1, fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			_, found, message := m.Lookup(arguments[0])
+			if message != "" {
+				return nil, NewRuntimeError(TypeError, message)
+			}
+			return found, nil
+		}}, true
+	case "remove":
+		return &nativeFunction{arity: 1, fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			value, _, message := m.Remove(arguments[0])
+			if message != "" {
+				return nil, NewRuntimeError(TypeError, message)
+			}
+			return value, nil
+		}}, true
+	case "keys":
+		return &nativeFunction{arity: 0, fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			keys := make([]interface{}, len(m.order))
+			for n, entry := range m.order {
+				keys[n] = entry.key
+			}
+			return NewList(keys), nil
+		}}, true
+	case "values":
+		return &nativeFunction{arity: 0, fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			values := make([]interface{}, len(m.order))
+			for n, entry := range m.order {
+				values[n] = entry.value
+			}
+			return NewList(values), nil
+		}}, true
+	case "len":
+		return &nativeFunction{arity: 0, fn: func(interpreter *Interpreter, arguments []interface{}) (interface{}, *RuntimeError) {
+			return int64(len(m.order)), nil
+		}}, true
+	}
+	return nil, false
+}
+
+// stringifyMap renders a map as {"a": 1, 2: [3]}, printing a map that
+// contains itself as {...}.
+func (i *Interpreter) stringifyMap(m *Map, seen map[interface{}]bool) string {
+	if seen[m] {
+		return "{...}"
+	}
+	seen[m] = true
+	defer delete(seen, m)
+
+	var builder strings.Builder
+	builder.WriteString("{")
+	for n, entry := range m.order {
+		if n > 0 {
+			builder.WriteString(", ")
+		}
+		builder.WriteString(i.stringifyElement(entry.key, seen))
+		builder.WriteString(": ")
+		builder.WriteString(i.stringifyElement(entry.value, seen))
+	}
+	builder.WriteString("}")
+	return builder.String()
+}
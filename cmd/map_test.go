@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func decimal(text string) *Decimal {
+	d, ok := parseDecimal(text)
+	if !ok {
+		panic("bad decimal " + text)
+	}
+	return d
+}
+
+func TestMapKeyEquality(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	tests := []struct {
+		name      string
+		stored    interface{}
+		lookedUp  interface{}
+		sameEntry bool
+	}{
+		{"int and float", int64(1), 1.0, true},
+		{"int and decimal", int64(1), decimal("1"), true},
+		{"int and decimal with scale", int64(1), decimal("1.00"), true},
+		{"int and rational", int64(1), big.NewRat(2, 2), true},
+		{"float and rational", 0.5, big.NewRat(1, 2), true},
+		{"decimal and rational", decimal("0.25"), big.NewRat(1, 4), true},
+		{"big int and float", huge, 1e20, true},
+		{"decimals of different scale", decimal("1.5"), decimal("1.50"), true},
+		{"float and decimal", 1.0, decimal("1"), false},
+		{"different ints", int64(1), int64(2), false},
+		{"int and string", int64(1), "1", false},
+		{"int and bool", int64(1), true, false},
+		{"nil and false", nil, false, false},
+		{"infinities", math.Inf(1), math.Inf(1), true},
+		{"opposite infinities", math.Inf(1), math.Inf(-1), false},
+		{"strings", "a", "a", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewMap()
+			if message := m.Put(test.stored, "stored"); message != "" {
+				t.Fatalf("Put(%v): %s", test.stored, message)
+			}
+			_, found, message := m.Lookup(test.lookedUp)
+			if message != "" {
+				t.Fatalf("Lookup(%v): %s", test.lookedUp, message)
+			}
+			if found != test.sameEntry {
+				t.Errorf("Lookup(%v) after Put(%v) found = %v, want %v", test.lookedUp, test.stored, found, test.sameEntry)
+			}
+
+			m.Put(test.lookedUp, "looked up")
+			want := 2
+			if test.sameEntry {
+				want = 1
+			}
+			if len(m.order) != want {
+				t.Errorf("Put(%v) after Put(%v) left %d entries, want %d", test.lookedUp, test.stored, len(m.order), want)
+			}
+		})
+	}
+}
+
+func TestMapUnhashableKeys(t *testing.T) {
+	for _, key := range []interface{}{math.NaN(), NewList(nil), NewMap()} {
+		if message := NewMap().Put(key, 1); message != unhashableKey {
+			t.Errorf("Put(%v) = %q, want %q", key, message, unhashableKey)
+		}
+	}
+}
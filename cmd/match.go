@@ -0,0 +1,164 @@
+package main
+
+import (
+	"go-intepreter/gen"
+	"go-intepreter/tokens"
+)
+
+// VisitMatchExpr tries the arms in order and evaluates to the body of the
+// first whose pattern matches and whose guard, if any, is true. It is a
+// runtime error for no arm to match.
+func (i *Interpreter) VisitMatchExpr(expr *gen.Match) interface{} {
+	subject := i.Evaluate(expr.Subject)
+	for _, arm := range expr.Arms {
+		if result, matched := i.tryArm(arm, subject); matched {
+			return result
+		}
+	}
+	i.runtimeError(MatchError, expr.Keyword, "No match arm matches "+i.stringifyElement(subject, make(map[interface{}]bool))+".")
+	return nil
+}
+
+// tryArm matches subject against one arm. The arm's bindings live in a scope
+// of their own, which the guard and body run in.
+func (i *Interpreter) tryArm(arm *gen.MatchArm, subject interface{}) (interface{}, bool) {
+	previous := i.environment
+	defer func() { i.environment = previous }()
+
+	i.environment = NewEnvironment(previous)
+	if !i.matchPattern(arm.Pattern, subject) {
+		return nil, false
+	}
+	if arm.Guard != nil && !i.isTruthy(i.Evaluate(arm.Guard)) {
+		return nil, false
+	}
+	return i.Evaluate(arm.Body), true
+}
+
+// matchPattern reports whether value matches pattern, defining the pattern's
+// bindings in the current environment as it goes.
+func (i *Interpreter) matchPattern(pattern gen.Pattern, value interface{}) bool {
+	switch p := pattern.(type) {
+	case *gen.Constant:
+		return i.isEqual(p.Value, value)
+
+	case *gen.Range:
+		if !isNumber(value) || mixesDecimalAndFloat(value, p.Low) || mixesDecimalAndFloat(value, p.High) {
+			return false
+		}
+		if p.Low != nil {
+			if c, ok := compareValues(value, p.Low); !ok || c < 0 {
+				return false
+			}
+		}
+		if p.High != nil {
+			if c, ok := compareValues(value, p.High); !ok || c > 0 || (c == 0 && !p.Inclusive) {
+				return false
+			}
+		}
+		return true
+
+	case *gen.TypeTest:
+		var matched bool
+		if p.Class != nil {
+			class, ok := i.Evaluate(p.Class).(*Class)
+			if !ok {
+				i.runtimeError(TypeError, p.TypeName, "'"+p.TypeName.Lexeme+"' is not a type.")
+			}
+			instance, ok := value.(*Instance)
+			matched = ok && instance.class.isSubclassOf(class)
+		} else {
+			matched = hasType(value, p.TypeName.Lexeme)
+		}
+		if matched && p.Name != nil {
+			i.environment.Define(p.Name.Lexeme, value)
+		}
+		return matched
+
+	case *gen.Binding:
+		i.environment.Define(p.Name.Lexeme, value)
+		return true
+
+	case *gen.Wildcard:
+		return true
+
+	case *gen.ListDestructure:
+		list, ok := value.(*List)
+		if !ok {
+			return false
+		}
+		n := len(list.elements)
+		if (p.Rest == nil && n != len(p.Before)) || n < len(p.Before)+len(p.After) {
+			return false
+		}
+		for k, element := range p.Before {
+			if !i.matchPattern(element, list.elements[k]) {
+				return false
+			}
+		}
+		for k, element := range p.After {
+			if !i.matchPattern(element, list.elements[n-len(p.After)+k]) {
+				return false
+			}
+		}
+		if p.Rest != nil && p.Rest.Type == tokens.IDENTIFIER {
+			rest := append([]interface{}(nil), list.elements[len(p.Before):n-len(p.After)]...)
+			i.environment.Define(p.Rest.Lexeme, NewList(rest))
+		}
+		return true
+
+	case *gen.MapDestructure:
+		m, ok := value.(*Map)
+		if !ok {
+			return false
+		}
+		for k, key := range p.Keys {
+			element, found, _ := m.Lookup(i.Evaluate(key))
+			if !found || !i.matchPattern(p.Values[k], element) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// hasType reports whether value is of the built-in type called name, one of
+// typeNames.
+func hasType(value interface{}, name string) bool {
+	switch name {
+	case "number":
+		return isNumber(value)
+	case "int":
+		return rankOf(value) == rankInt
+	case "decimal":
+		return rankOf(value) == rankDecimal
+	case "rational":
+		return rankOf(value) == rankRat
+	case "float":
+		return rankOf(value) == rankFloat
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "bool":
+		_, ok := value.(bool)
+		return ok
+	case "list":
+		_, ok := value.(*List)
+		return ok
+	case "map":
+		_, ok := value.(*Map)
+		return ok
+	case "function":
+		_, callable := value.(Callable)
+		_, class := value.(*Class)
+		return callable && !class
+	case "class":
+		_, ok := value.(*Class)
+		return ok
+	case "error":
+		_, ok := value.(*RuntimeError)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+
+	"go-intepreter/gen"
+)
+
+// evaluate parses, resolves and evaluates the expression source.
+func evaluate(t *testing.T, source string) interface{} {
+	t.Helper()
+	statements, parseErrors := NewParser(NewScanner(source + ";")).parse()
+	if len(parseErrors) > 0 || len(statements) != 1 {
+		t.Fatalf("%s does not parse as one expression", source)
+	}
+	expression, ok := statements[0].(*gen.Expression)
+	if !ok {
+		t.Fatalf("%s is not an expression", source)
+	}
+	interpreter := NewInterpreter()
+	NewResolver(interpreter).resolve(statements)
+	return interpreter.Evaluate(expression.Expression)
+}
+
+func TestMatchPatterns(t *testing.T) {
+	tests := []struct {
+		subject string
+		pattern string
+		want    string
+	}{
+		{`1`, `1`, "1"},
+		{`1.0`, `1`, "1"},
+		{`"a"`, `"a"`, "1"},
+		{`"a"`, `"b"`, "0"},
+		{`nil`, `nil`, "1"},
+		{`false`, `nil`, "0"},
+
+		{`5`, `1..10`, "1"},
+		{`10`, `1..10`, "0"},
+		{`10`, `1..=10`, "1"},
+		{`2.5`, `1..3`, "1"},
+		{`2.5d`, `1..3`, "1"},
+		{`100`, `5..`, "1"},
+		{`4`, `5..`, "0"},
+		{`"5"`, `1..10`, "0"},
+
+		{`3`, `is int`, "1"},
+		{`3.5`, `is int`, "0"},
+		{`3.5`, `is float`, "1"},
+		{`1.5d`, `is decimal`, "1"},
+		{`1.5d`, `is number`, "1"},
+		{`"s"`, `is string`, "1"},
+		{`[1]`, `is list`, "1"},
+		{`clock`, `is function`, "1"},
+		{`clock`, `is class`, "0"},
+		{`Error("x")`, `is error`, "1"},
+
+		{`[]`, `[]`, "1"},
+		{`[1, 2]`, `[1, 2]`, "1"},
+		{`[1, 2]`, `[1]`, "0"},
+		{`[1, 2, 3]`, `[1, ..]`, "1"},
+		{`[1, 2, 3]`, `[.., 3]`, "1"},
+		{`[1, 2, 3]`, `[1, .., 2]`, "0"},
+		{`[1]`, `[1, .., 1]`, "0"},
+		{`[1, [2, 3]]`, `[_, [_, 3]]`, "1"},
+		{`"[1]"`, `[1]`, "0"},
+
+		{`{"a": 1, "b": 2}`, `{"a": 1}`, "1"},
+		{`{"a": 1}`, `{"a": 2}`, "0"},
+		{`{"a": 1}`, `{"b": _}`, "0"},
+		{`{"a": [1, 2]}`, `{"a": [_, 2]}`, "1"},
+		{`[1]`, `{"a": _}`, "0"},
+	}
+	for _, test := range tests {
+		source := "match (" + test.subject + ") { " + test.pattern + " => 1, _ => 0 }"
+		got := NewInterpreter().stringify(evaluate(t, source))
+		if got != test.want {
+			t.Errorf("%s gave %s, want %s", source, got, test.want)
+		}
+	}
+}
+
+func TestMatchBindings(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`match (5) { n => n + 1 }`, "6"},
+		{`match (5) { n is int => n * 2, _ => 0 }`, "10"},
+		{`match ([1, 2, 3, 4]) { [first, ..middle, last] => "${first} ${middle} ${last}", _ => 0 }`, `1 [2, 3] 4`},
+		{`match ([1]) { [first, ..rest] => rest, _ => 0 }`, "[]"},
+		{`match ({"k": [7, 8]}) { {"k": [a, b]} => a * b, _ => 0 }`, "56"},
+		{`match (3) { n if n > 5 => "big", n => "small" }`, "small"},
+		{`match (7) { n if n > 5 => "big", n => "small" }`, "big"},
+		{`match ([1, 2]) { [a, b] if a > b => "down", [a, b] => "up", _ => 0 }`, "up"},
+	}
+	for _, test := range tests {
+		if got := NewInterpreter().stringify(evaluate(t, test.source)); got != test.want {
+			t.Errorf("%s gave %s, want %s", test.source, got, test.want)
+		}
+	}
+}
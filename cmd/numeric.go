@@ -0,0 +1,396 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"go-intepreter/tokens"
+)
+
+// Numbers at runtime form a tower, lowest rank first:
+//
+//	int64, *big.Int   integers
+//	*Decimal          fixed-point decimals
+//	*big.Rat          exact rationals
+//	float64           floats
+//
+// Integers are int64 while they fit and grow into *big.Int once they do not,
+// so integer arithmetic never overflows. Results are normalized: a *big.Int
+// that fits in int64 becomes an int64 and a *big.Rat with denominator 1
+// becomes an integer, so every value has exactly one representation.
+//
+// When the operands differ in rank the lower one is converted to the higher
+// one first. '/' on two integers truncates toward zero, unless exact division
+// is on, in which case a division with a remainder produces a rational. '%'
+// is the remainder of division truncated toward zero, so it takes the sign
+// of the left operand, for every kind of number.
+//
+// Decimals exist so money never passes through binary floating point, so
+// mixing a decimal with a float is a runtime error instead of a conversion.
+//
+// Numbers of different kinds compare by value, so 1 == 1.0 is true.
+
+type numberRank int
+
+const (
+	rankNone numberRank = iota
+	rankInt
+	rankDecimal
+	rankRat
+	rankFloat
+)
+
+// numberMode holds the settings that change how arithmetic behaves.
+type numberMode struct {
+	// exactDivision makes '/' on integers produce rationals instead of
+	// truncating.
+	exactDivision bool
+	// decimalScale is the number of fractional digits decimal products and
+	// quotients are rounded to, using rounding.
+	decimalScale int
+	rounding     roundingMode
+}
+
+func defaultNumberMode() numberMode {
+	return numberMode{
+		decimalScale: 16,
+		rounding:     roundHalfEven,
+	}
+}
+
+func rankOf(value interface{}) numberRank {
+	switch value.(type) {
+	case int64, *big.Int:
+		return rankInt
+	case *Decimal:
+		return rankDecimal
+	case *big.Rat:
+		return rankRat
+	case float64:
+		return rankFloat
+	}
+	return rankNone
+}
+
+func isNumber(value interface{}) bool {
+	return rankOf(value) != rankNone
+}
+
+func toBigInt(value interface{}) *big.Int {
+	switch v := value.(type) {
+	case int64:
+		return big.NewInt(v)
+	case *big.Int:
+		return v
+	}
+	return nil
+}
+
+func toRat(value interface{}) *big.Rat {
+	switch v := value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v)
+	case *big.Int:
+		return new(big.Rat).SetInt(v)
+	case *Decimal:
+		return v.Rat()
+	case *big.Rat:
+		return v
+	}
+	return nil
+}
+
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f
+	case *Decimal:
+		f, _ := v.Rat().Float64()
+		return f
+	case *big.Rat:
+		f, _ := v.Float64()
+		return f
+	case float64:
+		return v
+	}
+	return math.NaN()
+}
+
+// normalizeInt returns n as an int64 if it fits.
+func normalizeInt(n *big.Int) interface{} {
+	if n.IsInt64() {
+		return n.Int64()
+	}
+	return n
+}
+
+// normalizeRat returns r as an integer if it is whole.
+func normalizeRat(r *big.Rat) interface{} {
+	if r.IsInt() {
+		return normalizeInt(new(big.Int).Set(r.Num()))
+	}
+	return r
+}
+
+// mixesDecimalAndFloat reports whether one operand is a decimal and the
+// other a float.
+func mixesDecimalAndFloat(left, right interface{}) bool {
+	l, r := rankOf(left), rankOf(right)
+	return (l == rankDecimal && r == rankFloat) || (l == rankFloat && r == rankDecimal)
+}
+
+// arithmetic applies one of + - * / % to two numbers. On failure the result
+// is nil and the message describes the runtime error.
+func (m numberMode) arithmetic(op tokens.TokenType, left, right interface{}) (interface{}, string) {
+	if !isNumber(left) || !isNumber(right) {
+		return nil, "Operands must be numbers."
+	}
+	if mixesDecimalAndFloat(left, right) {
+		return nil, "Cannot mix decimal and float operands."
+	}
+
+	rank := max(rankOf(left), rankOf(right))
+	switch rank {
+	case rankInt:
+		l, lok := left.(int64)
+		r, rok := right.(int64)
+		if lok && rok {
+			if result, message, ok := m.smallIntArithmetic(op, l, r); ok {
+				return result, message
+			}
+		}
+		return m.bigIntArithmetic(op, toBigInt(left), toBigInt(right))
+	case rankDecimal:
+		return m.decimalArithmetic(op, toDecimal(left), toDecimal(right))
+	case rankRat:
+		return ratArithmetic(op, toRat(left), toRat(right))
+	}
+
+	lf, rf := toFloat(left), toFloat(right)
+	switch op {
+	case tokens.PLUS:
+		return lf + rf, ""
+	case tokens.MINUS:
+		return lf - rf, ""
+	case tokens.STAR:
+		return lf * rf, ""
+	case tokens.SLASH:
+		if rf == 0 {
+			return nil, "Division by zero."
+		}
+		return lf / rf, ""
+	case tokens.PERCENT:
+		if rf == 0 {
+			return nil, "Division by zero."
+		}
+		return math.Mod(lf, rf), ""
+	}
+	return nil, "Unknown arithmetic operator."
+}
+
+// smallIntArithmetic is the int64 fast path. ok is false when the result
+// does not fit and the big.Int path has to be taken.
+func (m numberMode) smallIntArithmetic(op tokens.TokenType, l, r int64) (result interface{}, message string, ok bool) {
+	switch op {
+	case tokens.PLUS:
+		sum := l + r
+		return sum, "", (sum^l)&(sum^r) >= 0
+	case tokens.MINUS:
+		diff := l - r
+		return diff, "", (l^r)&(l^diff) >= 0
+	case tokens.STAR:
+		product := l * r
+		return product, "", l == 0 || (product/l == r && !(l == -1 && r == math.MinInt64))
+	case tokens.SLASH:
+		if r == 0 {
+			return nil, "Division by zero.", true
+		}
+		if l == math.MinInt64 && r == -1 {
+			return nil, "", false
+		}
+		if m.exactDivision && l%r != 0 {
+			return big.NewRat(l, r), "", true
+		}
+		return l / r, "", true
+	case tokens.PERCENT:
+		if r == 0 {
+			return nil, "Division by zero.", true
+		}
+		return l % r, "", true
+	}
+	return nil, "Unknown arithmetic operator.", true
+}
+
+func (m numberMode) bigIntArithmetic(op tokens.TokenType, l, r *big.Int) (interface{}, string) {
+	result := new(big.Int)
+	switch op {
+	case tokens.PLUS:
+		result.Add(l, r)
+	case tokens.MINUS:
+		result.Sub(l, r)
+	case tokens.STAR:
+		result.Mul(l, r)
+	case tokens.SLASH:
+		if r.Sign() == 0 {
+			return nil, "Division by zero."
+		}
+		remainder := new(big.Int)
+		result.QuoRem(l, r, remainder)
+		if m.exactDivision && remainder.Sign() != 0 {
+			return new(big.Rat).SetFrac(l, r), ""
+		}
+	case tokens.PERCENT:
+		if r.Sign() == 0 {
+			return nil, "Division by zero."
+		}
+		result.Rem(l, r)
+	default:
+		return nil, "Unknown arithmetic operator."
+	}
+	return normalizeInt(result), ""
+}
+
+func ratArithmetic(op tokens.TokenType, l, r *big.Rat) (interface{}, string) {
+	result := new(big.Rat)
+	switch op {
+	case tokens.PLUS:
+		result.Add(l, r)
+	case tokens.MINUS:
+		result.Sub(l, r)
+	case tokens.STAR:
+		result.Mul(l, r)
+	case tokens.SLASH:
+		if r.Sign() == 0 {
+			return nil, "Division by zero."
+		}
+		result.Quo(l, r)
+	case tokens.PERCENT:
+		if r.Sign() == 0 {
+			return nil, "Division by zero."
+		}
+		quotient := new(big.Rat).Quo(l, r)
+		truncated := new(big.Int).Quo(quotient.Num(), quotient.Denom())
+		result.Sub(l, new(big.Rat).Mul(r, new(big.Rat).SetInt(truncated)))
+	default:
+		return nil, "Unknown arithmetic operator."
+	}
+	return normalizeRat(result), ""
+}
+
+// negate implements unary minus.
+func negate(value interface{}) (interface{}, string) {
+	switch v := value.(type) {
+	case int64:
+		if v == math.MinInt64 {
+			return new(big.Int).Neg(big.NewInt(v)), ""
+		}
+		return -v, ""
+	case *big.Int:
+		return normalizeInt(new(big.Int).Neg(v)), ""
+	case *Decimal:
+		return v.Neg(), ""
+	case *big.Rat:
+		return new(big.Rat).Neg(v), ""
+	case float64:
+		return -v, ""
+	}
+	return nil, "Operand must be a number."
+}
+
+// compareValues returns -1, 0 or 1 as left is less than, equal to or
+// greater than right. ok is false if the values are not ordered, which
+// includes comparisons involving NaN and between decimals and floats.
+func compareValues(left, right interface{}) (result int, ok bool) {
+	if mixesDecimalAndFloat(left, right) {
+		return 0, false
+	}
+	switch max(rankOf(left), rankOf(right)) {
+	case rankInt:
+		l, lok := left.(int64)
+		r, rok := right.(int64)
+		if lok && rok {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			}
+			return 0, true
+		}
+		return toBigInt(left).Cmp(toBigInt(right)), true
+	case rankDecimal:
+		return toDecimal(left).Cmp(toDecimal(right)), true
+	case rankRat:
+		return toRat(left).Cmp(toRat(right)), true
+	}
+
+	lf, rf := toFloat(left), toFloat(right)
+	switch {
+	case lf < rf:
+		return -1, true
+	case lf > rf:
+		return 1, true
+	case lf == rf:
+		return 0, true
+	}
+	return 0, false
+}
+
+// compareNumbers applies one of > >= < <= to two numbers.
+func compareNumbers(op tokens.TokenType, left, right interface{}) (interface{}, string) {
+	if !isNumber(left) || !isNumber(right) {
+		return nil, "Operands must be numbers."
+	}
+	if mixesDecimalAndFloat(left, right) {
+		return nil, "Cannot mix decimal and float operands."
+	}
+	c, ok := compareValues(left, right)
+	if !ok {
+		return false, ""
+	}
+	switch op {
+	case tokens.GREATER:
+		return c > 0, ""
+	case tokens.GREATER_EQUAL:
+		return c >= 0, ""
+	case tokens.LESS:
+		return c < 0, ""
+	case tokens.LESS_EQUAL:
+		return c <= 0, ""
+	}
+	return nil, "Unknown comparison operator."
+}
+
+// numbersEqual reports whether two numbers have the same value.
+func numbersEqual(left, right interface{}) bool {
+	c, ok := compareValues(left, right)
+	return ok && c == 0
+}
+
+// formatNumber renders a number so its kind can be told apart: floats
+// always show a fraction or exponent (3.0, 2.5, 1e+21), rationals are
+// written as a fraction (1/3) and decimals show their scale (12.50).
+func formatNumber(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return fmt.Sprintf("%d", v)
+	case *big.Int:
+		return v.String()
+	case *Decimal:
+		return v.String()
+	case *big.Rat:
+		return v.RatString()
+	case float64:
+		text := fmt.Sprintf("%v", v)
+		if !strings.ContainsAny(text, ".eIN") {
+			text += ".0"
+		}
+		return text
+	}
+	return fmt.Sprintf("%v", value)
+}
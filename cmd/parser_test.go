@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"go-intepreter/gen"
+	"go-intepreter/tokens"
+)
+
+// TestParseRecovery checks that the parser reports every syntax error and
+// keeps the statements around them.
+func TestParseRecovery(t *testing.T) {
+	statements, errors := NewParser(NewScanner("print 0;\nvar x = (1 + ;\nprint 2 +* 3;\nvar y = 1;")).parse()
+
+	want := []struct {
+		tokenType    tokens.TokenType
+		line, column int
+		message      string
+	}{
+		{tokens.SEMICOLON, 2, 14, "Expect expression."},
+		{tokens.STAR, 3, 10, "Expect expression."},
+	}
+	if len(errors) != len(want) {
+		t.Fatalf("got %d parse errors, want %d: %v", len(errors), len(want), errors)
+	}
+	for i, err := range errors {
+		span := err.Token.Span()
+		if err.Token.Type != want[i].tokenType || span.Line != want[i].line || span.Column != want[i].column || err.Message != want[i].message {
+			t.Errorf("error %d is %q at %s %q %d:%d, want %q at %s %d:%d", i, err.Message, err.Token.Type, err.Token.Lexeme, span.Line, span.Column, want[i].message, want[i].tokenType, want[i].line, want[i].column)
+		}
+	}
+
+	if len(statements) != 2 {
+		t.Fatalf("got %d statements, want the 2 well-formed ones", len(statements))
+	}
+	if _, ok := statements[0].(*gen.Print); !ok {
+		t.Errorf("statement 0 is %T, want the print", statements[0])
+	}
+	if v, ok := statements[1].(*gen.Var); !ok || v.Name.Lexeme != "y" {
+		t.Errorf("statement 1 is %T, want var y", statements[1])
+	}
+}
@@ -0,0 +1,511 @@
+package main
+
+import (
+	"go-intepreter/gen"
+	"go-intepreter/tokens"
+)
+
+// Resolver is a pass over the program, run after parsing and before
+// interpreting, that works out which declaration every variable refers to.
+// For each reference to a local variable it tells the interpreter how many
+// scopes out the variable lives and which slot of that scope holds it, so
+// lookups do not have to search by name and a closure always sees the
+// variable that was in scope where it was written. References it cannot find
+// are globals.
+//
+// It also reports the mistakes that can be found without running the
+// program, such as returning from top-level code.
+type Resolver struct {
+	interpreter *Interpreter
+
+	// scopes is the stack of local scopes around the node being resolved,
+	// innermost last.
+	scopes []map[string]*local
+
+	currentFunction functionType
+	currentClass    classType
+}
+
+// local is a variable declared in a local scope.
+type local struct {
+	// slot is the variable's position in its scope, counting declarations
+	// from 0.
+	slot int
+	// defined is false while the variable is declared but its initializer
+	// is still being resolved.
+	defined bool
+}
+
+type functionType int
+
+const (
+	functionNone functionType = iota
+	functionFunction
+	functionInitializer
+	functionMethod
+)
+
+type classType int
+
+const (
+	classNone classType = iota
+	classClass
+	classSubclass
+)
+
+func NewResolver(interpreter *Interpreter) *Resolver {
+	return &Resolver{interpreter: interpreter}
+}
+
+// resolve resolves a whole program. Errors are reported as they are found.
+func (r *Resolver) resolve(statements []gen.Stmt) {
+	for _, stmt := range statements {
+		r.resolveStmt(stmt)
+	}
+}
+
+func (r *Resolver) resolveStmt(stmt gen.Stmt) {
+	stmt.Accept(r)
+}
+
+func (r *Resolver) resolveExpr(expr gen.Expr) {
+	expr.Accept(r)
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, make(map[string]*local))
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+// declare adds name to the innermost scope, not yet ready to be read.
+func (r *Resolver) declare(name *tokens.Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	scope := r.scopes[len(r.scopes)-1]
+	if _, exists := scope[name.Lexeme]; exists {
+		r.error(name, "Already a variable with this name in this scope.")
+		return
+	}
+	scope[name.Lexeme] = &local{slot: len(scope)}
+}
+
+func (r *Resolver) define(name *tokens.Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+	if variable, ok := r.scopes[len(r.scopes)-1][name.Lexeme]; ok {
+		variable.defined = true
+	}
+}
+
+// defineImplicit adds 'this' or 'super' as the only variable of a new scope,
+// matching the environment the interpreter creates for it.
+func (r *Resolver) defineImplicit(name string) {
+	r.beginScope()
+	r.scopes[len(r.scopes)-1][name] = &local{slot: 0, defined: true}
+}
+
+// resolveLocal records how far out the innermost scope declaring name is and
+// the slot name has there.
+func (r *Resolver) resolveLocal(expr gen.Expr, name *tokens.Token) {
+	for n := len(r.scopes) - 1; n >= 0; n-- {
+		if variable, ok := r.scopes[n][name.Lexeme]; ok {
+			r.interpreter.resolve(expr, len(r.scopes)-1-n, variable.slot)
+			return
+		}
+	}
+}
+
+func (r *Resolver) resolveFunction(function *gen.Function, kind functionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = kind
+
+	r.beginScope()
+	for _, param := range function.Params {
+		r.declare(param)
+		r.define(param)
+	}
+	r.resolve(function.Body)
+	r.endScope()
+
+	r.currentFunction = enclosingFunction
+}
+
+// warning reports something that is likely a mistake but does not stop the
+// program from running.
+func (r *Resolver) warning(token *tokens.Token, message string) {
+	warning(token.Span(), message)
+}
+
+// error reports a mistake at token. Resolving carries on afterwards, so every
+// mistake is reported.
+func (r *Resolver) error(token *tokens.Token, message string) {
+	where := " at '" + token.Lexeme + "'"
+	if token.Type == tokens.EOF {
+		where = " at end"
+	}
+	report(token.Span(), where, message, "")
+	hadError = true
+}
+
+func (r *Resolver) VisitBlockStmt(stmt *gen.Block) interface{} {
+	r.beginScope()
+	r.resolve(stmt.Statements)
+	r.endScope()
+	return nil
+}
+
+func (r *Resolver) VisitClassStmt(stmt *gen.Class) interface{} {
+	enclosingClass := r.currentClass
+	r.currentClass = classClass
+
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+
+	if stmt.Superclass != nil {
+		if stmt.Superclass.Name.Lexeme == stmt.Name.Lexeme {
+			r.error(stmt.Superclass.Name, "A class can't inherit from itself.")
+		}
+		r.currentClass = classSubclass
+		r.resolveExpr(stmt.Superclass)
+
+		r.defineImplicit("super")
+	}
+
+	r.defineImplicit("this")
+	for _, method := range stmt.Methods {
+		kind := functionMethod
+		if method.Name.Lexeme == "init" {
+			kind = functionInitializer
+		}
+		r.resolveFunction(method, kind)
+	}
+	r.endScope()
+
+	if stmt.Superclass != nil {
+		r.endScope()
+	}
+	r.currentClass = enclosingClass
+	return nil
+}
+
+func (r *Resolver) VisitExpressionStmt(stmt *gen.Expression) interface{} {
+	r.resolveExpr(stmt.Expression)
+	return nil
+}
+
+// VisitFunctionStmt defines the name before resolving the body so that the
+// function can call itself.
+func (r *Resolver) VisitFunctionStmt(stmt *gen.Function) interface{} {
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+	r.resolveFunction(stmt, functionFunction)
+	return nil
+}
+
+func (r *Resolver) VisitIfStmt(stmt *gen.If) interface{} {
+	r.resolveExpr(stmt.Condition)
+	r.resolveStmt(stmt.ThenBranch)
+	if stmt.ElseBranch != nil {
+		r.resolveStmt(stmt.ElseBranch)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitPrintStmt(stmt *gen.Print) interface{} {
+	r.resolveExpr(stmt.Expression)
+	return nil
+}
+
+func (r *Resolver) VisitReturnStmt(stmt *gen.Return) interface{} {
+	if r.currentFunction == functionNone {
+		r.error(stmt.Keyword, "Can't return from top-level code.")
+	}
+	if stmt.Value != nil {
+		if r.currentFunction == functionInitializer {
+			r.error(stmt.Keyword, "Can't return a value from an initializer.")
+		}
+		r.resolveExpr(stmt.Value)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitThrowStmt(stmt *gen.Throw) interface{} {
+	r.resolveExpr(stmt.Value)
+	return nil
+}
+
+// VisitTryStmt gives each block its own scope. The catch clause's name is
+// declared in the catch block's scope, as the interpreter defines it there.
+func (r *Resolver) VisitTryStmt(stmt *gen.Try) interface{} {
+	r.beginScope()
+	r.resolve(stmt.Body)
+	r.endScope()
+
+	if stmt.CatchBody != nil {
+		r.beginScope()
+		if stmt.CatchName != nil {
+			r.declare(stmt.CatchName)
+			r.define(stmt.CatchName)
+		}
+		r.resolve(stmt.CatchBody)
+		r.endScope()
+	}
+
+	if stmt.FinallyBody != nil {
+		r.beginScope()
+		r.resolve(stmt.FinallyBody)
+		r.endScope()
+	}
+	return nil
+}
+
+func (r *Resolver) VisitVarStmt(stmt *gen.Var) interface{} {
+	r.declare(stmt.Name)
+	if stmt.Initializer != nil {
+		r.resolveExpr(stmt.Initializer)
+	}
+	r.define(stmt.Name)
+	return nil
+}
+
+func (r *Resolver) VisitWhileStmt(stmt *gen.While) interface{} {
+	r.resolveExpr(stmt.Condition)
+	r.resolveStmt(stmt.Body)
+	return nil
+}
+
+func (r *Resolver) VisitAssignExpr(expr *gen.Assign) interface{} {
+	r.resolveExpr(expr.Value)
+	r.resolveLocal(expr, expr.Name)
+	return nil
+}
+
+func (r *Resolver) VisitBinaryExpr(expr *gen.Binary) interface{} {
+	r.resolveExpr(expr.Left)
+	r.resolveExpr(expr.Right)
+	return nil
+}
+
+func (r *Resolver) VisitCallExpr(expr *gen.Call) interface{} {
+	r.resolveExpr(expr.Callee)
+	for _, argument := range expr.Arguments {
+		r.resolveExpr(argument)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitCommaExpr(expr *gen.Comma) interface{} {
+	r.resolveExpr(expr.Left)
+	r.resolveExpr(expr.Right)
+	return nil
+}
+
+func (r *Resolver) VisitCompoundExpr(expr *gen.Compound) interface{} {
+	r.resolveExpr(expr.Target)
+	r.resolveExpr(expr.Value)
+	return nil
+}
+
+func (r *Resolver) VisitConditionalExpr(expr *gen.Conditional) interface{} {
+	r.resolveExpr(expr.Condition)
+	r.resolveExpr(expr.ThenBranch)
+	r.resolveExpr(expr.ElseBranch)
+	return nil
+}
+
+func (r *Resolver) VisitGetExpr(expr *gen.Get) interface{} {
+	r.resolveExpr(expr.Object)
+	return nil
+}
+
+func (r *Resolver) VisitGroupingExpr(expr *gen.Grouping) interface{} {
+	r.resolveExpr(expr.Expression)
+	return nil
+}
+
+func (r *Resolver) VisitIncrementExpr(expr *gen.Increment) interface{} {
+	r.resolveExpr(expr.Target)
+	return nil
+}
+
+func (r *Resolver) VisitIndexExpr(expr *gen.Index) interface{} {
+	r.resolveExpr(expr.Object)
+	r.resolveExpr(expr.Index)
+	return nil
+}
+
+func (r *Resolver) VisitInterpolationExpr(expr *gen.Interpolation) interface{} {
+	for _, part := range expr.Parts {
+		r.resolveExpr(part)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitLiteralExpr(expr *gen.Literal) interface{} {
+	return nil
+}
+
+func (r *Resolver) VisitListExpr(expr *gen.List) interface{} {
+	for _, element := range expr.Elements {
+		r.resolveExpr(element)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitLogicalExpr(expr *gen.Logical) interface{} {
+	r.resolveExpr(expr.Left)
+	r.resolveExpr(expr.Right)
+	return nil
+}
+
+func (r *Resolver) VisitMapExpr(expr *gen.Map) interface{} {
+	for n := range expr.Keys {
+		r.resolveExpr(expr.Keys[n])
+		r.resolveExpr(expr.Values[n])
+	}
+	return nil
+}
+
+// VisitMatchExpr resolves each arm in a scope holding its bindings. It warns
+// when no arm is certain to match, since a value that reaches the end of the
+// arms is a runtime error.
+func (r *Resolver) VisitMatchExpr(expr *gen.Match) interface{} {
+	r.resolveExpr(expr.Subject)
+
+	hasDefault := false
+	for _, arm := range expr.Arms {
+		r.beginScope()
+		arm.Pattern.Accept(r)
+		if arm.Guard != nil {
+			r.resolveExpr(arm.Guard)
+		}
+		r.resolveExpr(arm.Body)
+		r.endScope()
+
+		switch arm.Pattern.(type) {
+		case *gen.Wildcard, *gen.Binding:
+			hasDefault = hasDefault || arm.Guard == nil
+		}
+	}
+	if !hasDefault {
+		r.warning(expr.Keyword, "Match has no default arm; add '_ => ...' to handle every value.")
+	}
+	return nil
+}
+
+func (r *Resolver) VisitConstantPattern(pattern *gen.Constant) interface{} {
+	return nil
+}
+
+func (r *Resolver) VisitRangePattern(pattern *gen.Range) interface{} {
+	return nil
+}
+
+func (r *Resolver) VisitTypeTestPattern(pattern *gen.TypeTest) interface{} {
+	if pattern.Class != nil {
+		r.resolveExpr(pattern.Class)
+	}
+	if pattern.Name != nil {
+		r.declare(pattern.Name)
+		r.define(pattern.Name)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitBindingPattern(pattern *gen.Binding) interface{} {
+	r.declare(pattern.Name)
+	r.define(pattern.Name)
+	return nil
+}
+
+func (r *Resolver) VisitWildcardPattern(pattern *gen.Wildcard) interface{} {
+	return nil
+}
+
+func (r *Resolver) VisitListDestructurePattern(pattern *gen.ListDestructure) interface{} {
+	// The interpreter binds the elements before the rest, so they are
+	// declared in that order too.
+	for _, element := range pattern.Before {
+		element.Accept(r)
+	}
+	for _, element := range pattern.After {
+		element.Accept(r)
+	}
+	if pattern.Rest != nil && pattern.Rest.Type == tokens.IDENTIFIER {
+		r.declare(pattern.Rest)
+		r.define(pattern.Rest)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitMapDestructurePattern(pattern *gen.MapDestructure) interface{} {
+	for n := range pattern.Keys {
+		r.resolveExpr(pattern.Keys[n])
+		pattern.Values[n].Accept(r)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitSetExpr(expr *gen.Set) interface{} {
+	r.resolveExpr(expr.Value)
+	r.resolveExpr(expr.Object)
+	return nil
+}
+
+func (r *Resolver) VisitSetIndexExpr(expr *gen.SetIndex) interface{} {
+	r.resolveExpr(expr.Object)
+	r.resolveExpr(expr.Index)
+	r.resolveExpr(expr.Value)
+	return nil
+}
+
+func (r *Resolver) VisitSliceExpr(expr *gen.Slice) interface{} {
+	r.resolveExpr(expr.Object)
+	if expr.Start != nil {
+		r.resolveExpr(expr.Start)
+	}
+	if expr.End != nil {
+		r.resolveExpr(expr.End)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitSuperExpr(expr *gen.Super) interface{} {
+	switch r.currentClass {
+	case classNone:
+		r.error(expr.Keyword, "Can't use 'super' outside of a class.")
+	case classClass:
+		r.error(expr.Keyword, "Can't use 'super' in a class with no superclass.")
+	}
+	r.resolveLocal(expr, expr.Keyword)
+	return nil
+}
+
+func (r *Resolver) VisitThisExpr(expr *gen.This) interface{} {
+	if r.currentClass == classNone {
+		r.error(expr.Keyword, "Can't use 'this' outside of a class.")
+		return nil
+	}
+	r.resolveLocal(expr, expr.Keyword)
+	return nil
+}
+
+func (r *Resolver) VisitUnaryExpr(expr *gen.Unary) interface{} {
+	r.resolveExpr(expr.Right)
+	return nil
+}
+
+func (r *Resolver) VisitVariableExpr(expr *gen.Variable) interface{} {
+	if len(r.scopes) > 0 {
+		if variable, declared := r.scopes[len(r.scopes)-1][expr.Name.Lexeme]; declared && !variable.defined {
+			r.error(expr.Name, "Can't read local variable in its own initializer.")
+		}
+	}
+	r.resolveLocal(expr, expr.Name)
+	return nil
+}
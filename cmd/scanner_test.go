@@ -0,0 +1,139 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"go-intepreter/tokens"
+)
+
+// scanAll scans source, read through wrap, to the end.
+func scanAll(source string, trivia bool, wrap func(io.Reader) io.Reader) []*tokens.Token {
+	scanner := NewReaderScanner(wrap(strings.NewReader(source)))
+	scanner.PreserveTrivia = trivia
+	all, _ := scanner.ScanTokens()
+	return all
+}
+
+var readers = []struct {
+	name string
+	wrap func(io.Reader) io.Reader
+}{
+	{"whole", func(r io.Reader) io.Reader { return r }},
+	{"one byte at a time", iotest.OneByteReader},
+	{"half chunks", iotest.HalfReader},
+}
+
+// scannerSources covers the ways a token can run across the scanner's
+// read chunks: many small tokens, one token longer than a chunk, and
+// trivia longer than a chunk.
+var scannerSources = []struct {
+	name   string
+	source string
+}{
+	{"empty", ""},
+	{"small program", "var a = 1; // one\nprint a + 2.5d;\n"},
+	{"many lines", strings.Repeat("var x = \"abc\" + 12.5; /* c */ print x;\n", 200)},
+	{"long string", "print \"" + strings.Repeat("ab", 3*readChunk) + "\";\n"},
+	{"long comment", "// " + strings.Repeat("-", 2*readChunk) + "\nprint 1;"},
+	{"long whitespace", "print" + strings.Repeat(" ", readChunk+7) + "1;"},
+	{"interpolation", strings.Repeat("print \"a${1 + 2}b${\"c\"}\";\n", 300)},
+	{"invalid utf-8", "print \"a\xffb\";\n" + strings.Repeat("var \xc3 = 1;\n", 600)},
+	{"multi-byte runes", strings.Repeat("print \"héllo wörld ✓\";\n", 400)},
+	{"unterminated string", "var x = \"abc;\n"},
+}
+
+func TestReconstruct(t *testing.T) {
+	for _, test := range scannerSources {
+		for _, reader := range readers {
+			t.Run(test.name+"/"+reader.name, func(t *testing.T) {
+				got := tokens.Reconstruct(scanAll(test.source, true, reader.wrap))
+				if got != test.source {
+					t.Errorf("Reconstruct gave %d bytes, want the %d-byte source back", len(got), len(test.source))
+				}
+			})
+		}
+	}
+}
+
+// TestTokenOffsets checks that every token's span covers its lexeme in the
+// source and that Line and Column agree with the offset.
+func TestTokenOffsets(t *testing.T) {
+	for _, test := range scannerSources {
+		for _, reader := range readers {
+			t.Run(test.name+"/"+reader.name, func(t *testing.T) {
+				for _, token := range scanAll(test.source, false, reader.wrap) {
+					span := token.Span()
+					if token.Type == tokens.EOF {
+						if span.Start != len(test.source) {
+							t.Errorf("EOF starts at %d, want %d", span.Start, len(test.source))
+						}
+						continue
+					}
+					if span.Start < 0 || span.End > len(test.source) || span.Start > span.End {
+						t.Fatalf("%s %q has span %d-%d outside the %d-byte source", token.Type, token.Lexeme, span.Start, span.End, len(test.source))
+					}
+					if text := test.source[span.Start:span.End]; text != token.Lexeme {
+						t.Fatalf("%s at %d-%d covers %q, want lexeme %q", token.Type, span.Start, span.End, text, token.Lexeme)
+					}
+					before := test.source[:span.Start]
+					line := strings.Count(before, "\n") + 1
+					column := len([]rune(before[strings.LastIndexByte(before, '\n')+1:])) + 1
+					if span.Line != line || span.Column != column {
+						t.Fatalf("%s %q at offset %d is at %d:%d, want %d:%d", token.Type, token.Lexeme, span.Start, span.Line, span.Column, line, column)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSourceLine(t *testing.T) {
+	source := strings.Repeat("var a = 1;\n", 1000) + "var b = @;\n" + strings.Repeat("print a;\n", 1000) + "print \"last\""
+	for _, keep := range []bool{false, true} {
+		for _, reader := range readers {
+			scanner := NewReaderScanner(reader.wrap(strings.NewReader(source)))
+			scanner.KeepLines = keep
+			scanner.ScanTokens()
+
+			lines := strings.Split(source, "\n")
+			for n := 1; n <= len(lines); n++ {
+				text, begin, ok := scanner.SourceLine(n)
+				// Without KeepLines only the line with the diagnostic and
+				// the line scanning ended on are kept.
+				want := keep || n == 1001 || n == len(lines)
+				if ok != want {
+					t.Fatalf("KeepLines %v, %s: SourceLine(%d) ok = %v, want %v", keep, reader.name, n, ok, want)
+				}
+				if !ok {
+					continue
+				}
+				wantBegin := len(strings.Join(lines[:n-1], "\n")) + 1
+				if n == 1 {
+					wantBegin = 0
+				}
+				if text != lines[n-1] || begin != wantBegin {
+					t.Fatalf("KeepLines %v, %s: SourceLine(%d) = %q at %d, want %q at %d", keep, reader.name, n, text, begin, lines[n-1], wantBegin)
+				}
+			}
+		}
+	}
+}
+
+// TestSourceLineAhead asks for the line being scanned before the scanner has
+// read to its end.
+func TestSourceLineAhead(t *testing.T) {
+	line := "var b = 1 + " + strings.Repeat("x", 3*readChunk)
+	scanner := NewReaderScanner(iotest.OneByteReader(strings.NewReader(line + "\nprint b;")))
+	for token := scanner.Next(); token.Type != tokens.PLUS; token = scanner.Next() {
+	}
+	text, begin, ok := scanner.SourceLine(1)
+	if !ok || text != line || begin != 0 {
+		t.Fatalf("SourceLine(1) = %d bytes at %d, %v, want the %d-byte first line at 0", len(text), begin, ok, len(line))
+	}
+	if token := scanner.Next(); token.Lexeme != strings.Repeat("x", 3*readChunk) {
+		t.Fatalf("after SourceLine the next token is %q", token.Lexeme)
+	}
+}
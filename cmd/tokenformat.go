@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+
+	"go-intepreter/tokens"
+)
+
+// Output formats for the tokenize command.
+const (
+	formatText   = "text"   // Token.String(), one per line
+	formatJSON   = "jsonl"  // one JSON object per line
+	formatBinary = "binary" // length-delimited protobuf messages
+)
+
+var tokenFormats = []string{formatText, formatJSON, formatBinary}
+
+// writeToken writes a token to w in the given format.
+func writeToken(w *bufio.Writer, format string, token *tokens.Token) {
+	switch format {
+	case formatJSON:
+		data, _ := json.Marshal(newJSONToken(token))
+		w.Write(data)
+		w.WriteByte('\n')
+	case formatBinary:
+		message := appendTokenMessage(nil, token)
+		w.Write(binary.AppendUvarint(nil, uint64(len(message))))
+		w.Write(message)
+	default:
+		fmt.Fprintln(w, token)
+	}
+}
+
+// JSON Lines format. Each line is an object like
+//
+//	{"type":"NUMBER","lexeme":"0xFF","literal":{"kind":"int","value":255},
+//	 "line":1,"column":9,"start":8,"end":12}
+//
+// Literal kinds are "string", "int", "bigint", "decimal" and "float".
+// Big integers and decimals carry their value as a string so no precision is
+// lost, as do floats that JSON cannot represent (+Inf, -Inf, NaN). The
+// leading and trailing arrays appear when trivia is kept.
+
+type jsonToken struct {
+	Type     tokens.TokenType `json:"type"`
+	Lexeme   string           `json:"lexeme"`
+	Literal  *jsonLiteral     `json:"literal,omitempty"`
+	Line     int              `json:"line"`
+	Column   int              `json:"column"`
+	Start    int              `json:"start"`
+	End      int              `json:"end"`
+	Leading  []jsonTrivia     `json:"leading,omitempty"`
+	Trailing []jsonTrivia     `json:"trailing,omitempty"`
+}
+
+type jsonLiteral struct {
+	Kind  string      `json:"kind"`
+	Value interface{} `json:"value"`
+}
+
+type jsonTrivia struct {
+	Kind tokens.TriviaKind `json:"kind"`
+	Text string            `json:"text"`
+}
+
+func newJSONToken(token *tokens.Token) jsonToken {
+	result := jsonToken{
+		Type:   token.Type,
+		Lexeme: token.Lexeme,
+		Line:   token.Line,
+		Column: token.Column,
+		Start:  token.Start,
+		End:    token.End,
+	}
+
+	switch v := token.Literal.(type) {
+	case string:
+		result.Literal = &jsonLiteral{Kind: "string", Value: v}
+	case int64:
+		result.Literal = &jsonLiteral{Kind: "int", Value: v}
+	case *big.Int:
+		result.Literal = &jsonLiteral{Kind: "bigint", Value: v.String()}
+	case *Decimal:
+		result.Literal = &jsonLiteral{Kind: "decimal", Value: v.String()}
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			result.Literal = &jsonLiteral{Kind: "float", Value: fmt.Sprintf("%v", v)}
+		} else {
+			result.Literal = &jsonLiteral{Kind: "float", Value: v}
+		}
+	}
+
+	for _, trivia := range token.LeadingTrivia {
+		result.Leading = append(result.Leading, jsonTrivia{Kind: trivia.Kind, Text: trivia.Text})
+	}
+	for _, trivia := range token.TrailingTrivia {
+		result.Trailing = append(result.Trailing, jsonTrivia{Kind: trivia.Kind, Text: trivia.Text})
+	}
+	return result
+}
+
+// Binary format. The output is a sequence of protobuf messages, each
+// preceded by its length as a varint (the usual "delimited" stream), so it
+// can be read with any protobuf library or inspected with
+// protoc --decode_raw. A token message has these fields:
+//
+//	1  string  type
+//	2  string  lexeme
+//	3  uint64  line
+//	4  uint64  column
+//	5  uint64  start
+//	6  uint64  end
+//	7  sint64  int literal
+//	8  double  float literal
+//	9  string  string literal
+//	10 string  big integer literal, in decimal
+//	11 string  decimal literal
+//	12 Trivia  leading trivia (repeated)
+//	13 Trivia  trailing trivia (repeated)
+//
+// and a Trivia message has 1 string kind and 2 string text. At most one of
+// the literal fields is present.
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func appendTag(b []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+func appendVarintField(b []byte, field int, value uint64) []byte {
+	b = appendTag(b, field, wireVarint)
+	return binary.AppendUvarint(b, value)
+}
+
+func appendBytesField(b []byte, field int, value []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func appendTokenMessage(b []byte, token *tokens.Token) []byte {
+	b = appendBytesField(b, 1, []byte(token.Type))
+	b = appendBytesField(b, 2, []byte(token.Lexeme))
+	b = appendVarintField(b, 3, uint64(token.Line))
+	b = appendVarintField(b, 4, uint64(token.Column))
+	b = appendVarintField(b, 5, uint64(token.Start))
+	b = appendVarintField(b, 6, uint64(token.End))
+
+	switch v := token.Literal.(type) {
+	case int64:
+		b = appendTag(b, 7, wireVarint)
+		b = binary.AppendVarint(b, v) // zigzag, as sint64
+	case float64:
+		b = appendTag(b, 8, wireFixed64)
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	case string:
+		b = appendBytesField(b, 9, []byte(v))
+	case *big.Int:
+		b = appendBytesField(b, 10, []byte(v.String()))
+	case *Decimal:
+		b = appendBytesField(b, 11, []byte(v.String()))
+	}
+
+	for _, trivia := range token.LeadingTrivia {
+		b = appendBytesField(b, 12, appendTriviaMessage(nil, trivia))
+	}
+	for _, trivia := range token.TrailingTrivia {
+		b = appendBytesField(b, 13, appendTriviaMessage(nil, trivia))
+	}
+	return b
+}
+
+func appendTriviaMessage(b []byte, trivia tokens.Trivia) []byte {
+	b = appendBytesField(b, 1, []byte(trivia.Kind))
+	return appendBytesField(b, 2, []byte(trivia.Text))
+}
@@ -1,25 +1,107 @@
 package gen
 
+//go:generate go run ./genast
+
 import (
+	"bytes"
 	"fmt"
+	"go/format"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
-type ExprType string
+// NodeType describes one AST node: its name and its fields, written as a
+// comma separated list of Go field declarations.
+type NodeType struct {
+	Name   string
+	Fields string
+}
 
-const (
-	BINARY   ExprType = "Left Expr, Right Expr, Operator Token"
-	GROUPING ExprType = "Expression Expr"
-	LITERAL  ExprType = "Value interface{}"
-	UNARY    ExprType = "Operator Token, Right Expr"
-)
+// ExprTypes are the expression nodes, generated into generated.go.
+var ExprTypes = []NodeType{
+	{"Binary", "Left Expr, Right Expr, Operator *tokens.Token"},
+	{"Unary", "Operator *tokens.Token, Right Expr"},
+	{"Grouping", "Expression Expr"},
+	{"Literal", "Value interface{}, Token *tokens.Token"},
+	// Interpolation alternates string Literal pieces with the expressions
+	// spliced between them.
+	{"Interpolation", "Parts []Expr"},
+	{"Variable", "Name *tokens.Token"},
+	{"Assign", "Name *tokens.Token, Value Expr"},
+	// Logical is 'and' and 'or', kept apart from Binary because they only
+	// evaluate Right when Left does not decide the result.
+	{"Logical", "Left Expr, Right Expr, Operator *tokens.Token"},
+	// Call keeps the closing parenthesis to report errors in the call at.
+	{"Call", "Callee Expr, Paren *tokens.Token, Arguments []Expr"},
+	{"Get", "Object Expr, Name *tokens.Token"},
+	{"Set", "Object Expr, Name *tokens.Token, Value Expr"},
+	{"This", "Keyword *tokens.Token"},
+	{"Super", "Keyword *tokens.Token, Method *tokens.Token"},
+	// Conditional is cond ? then : else; only the chosen branch is evaluated.
+	{"Conditional", "Condition Expr, ThenBranch Expr, ElseBranch Expr"},
+	// Comma evaluates Left, discards it, and evaluates to Right.
+	{"Comma", "Left Expr, Right Expr"},
+	// Compound is a compound assignment such as a += b, and Increment is ++
+	// or -- before (Prefix) or after the target. Target is a Variable, Get or
+	// Index and is evaluated only once.
+	{"Compound", "Target Expr, Operator *tokens.Token, Value Expr"},
+	{"Increment", "Target Expr, Operator *tokens.Token, Prefix bool"},
+	// List is a list literal; Bracket is its '['.
+	{"List", "Bracket *tokens.Token, Elements []Expr"},
+	// Map is a map literal; Brace is its '{' and Keys[i] maps to Values[i].
+	{"Map", "Brace *tokens.Token, Keys []Expr, Values []Expr"},
+	// Index, Slice and SetIndex keep the closing ']' to report errors at.
+	// Either bound of a Slice may be nil.
+	{"Index", "Object Expr, Bracket *tokens.Token, Index Expr"},
+	{"Slice", "Object Expr, Bracket *tokens.Token, Start Expr, End Expr"},
+	{"SetIndex", "Object Expr, Bracket *tokens.Token, Index Expr, Value Expr"},
+	// Match tries each arm in turn against the value of Subject.
+	{"Match", "Keyword *tokens.Token, Subject Expr, Arms []*MatchArm"},
+}
 
-var exprTypeNames = map[ExprType]string{
-	BINARY:   "Binary",
-	UNARY:    "Unary",
-	GROUPING: "Grouping",
-	LITERAL:  "Literal",
+// StmtTypes are the statement nodes, generated into generated_stmt.go.
+var StmtTypes = []NodeType{
+	{"Expression", "Expression Expr"},
+	{"Print", "Expression Expr"},
+	{"Var", "Name *tokens.Token, Initializer Expr"},
+	{"Block", "Statements []Stmt"},
+	{"If", "Condition Expr, ThenBranch Stmt, ElseBranch Stmt"},
+	{"While", "Condition Expr, Body Stmt"},
+	{"Function", "Name *tokens.Token, Params []*tokens.Token, Body []Stmt"},
+	{"Return", "Keyword *tokens.Token, Value Expr"},
+	// Class has a nil Superclass when it does not inherit.
+	{"Class", "Name *tokens.Token, Superclass *Variable, Methods []*Function"},
+	{"Throw", "Keyword *tokens.Token, Value Expr"},
+	// Try has a nil CatchBody when there is no catch clause and a nil
+	// FinallyBody when there is no finally clause; it always has one of the
+	// two. CatchName is nil when the catch clause does not name the error.
+	{"Try", "Keyword *tokens.Token, Body []Stmt, CatchName *tokens.Token, CatchBody []Stmt, FinallyBody []Stmt"},
+}
+
+// PatternTypes are the patterns of match arms, generated into
+// generated_pattern.go.
+var PatternTypes = []NodeType{
+	// Constant matches values == to Value.
+	{"Constant", "Value interface{}, Token *tokens.Token"},
+	// Range matches numbers from Low up to High, including High only
+	// if Inclusive. A nil bound leaves that end open.
+	{"Range", "Low interface{}, High interface{}, Inclusive bool, Operator *tokens.Token"},
+	// TypeTest matches values of the type named by TypeName and binds them
+	// to Name unless it is nil. Class is the Variable naming the class when
+	// TypeName is not a built-in type name.
+	{"TypeTest", "Name *tokens.Token, TypeName *tokens.Token, Class Expr"},
+	{"Binding", "Name *tokens.Token"},
+	{"Wildcard", "Token *tokens.Token"},
+	// ListDestructure matches lists that start with elements matching Before
+	// and end with ones matching After, binding the elements in between to
+	// Rest. Rest is the '..' token itself when they are not bound, and nil
+	// when there is no '..', in which case the list must have exactly
+	// len(Before) elements and After is empty.
+	{"ListDestructure", "Bracket *tokens.Token, Before []Pattern, Rest *tokens.Token, After []Pattern"},
+	// MapDestructure matches maps holding each of Keys with a value matching
+	// the pattern at the same position in Values. Other keys are ignored.
+	{"MapDestructure", "Brace *tokens.Token, Keys []Expr, Values []Pattern"},
 }
 
 func check(e error) {
@@ -28,121 +110,89 @@ func check(e error) {
 	}
 }
 
-func DefineAST(outputdir string, packageName string, types []ExprType) {
-	//create file
-	path := outputdir
-	f, err := os.Create(path)
-	check(err)
-	defer f.Close()
-
-	//define base and inserts
-	_, err = fmt.Fprintln(f, "package ", packageName)
-	check(err)
-
-	line := fmt.Sprintln(`import "github.com/codecrafters-io/interpreter-starter-go/tokens"`)
-	//imports
-	fmt.Fprintln(f, line)
-	//expr interface
-	_, err = fmt.Fprintln(f, "type Expr interface {")
-	check(err)
-	_, err = fmt.Fprintln(f, "	Accept(visitor VisitorExpr) interface{}")
-	check(err)
-	_, err = fmt.Fprintln(f, "}")
-	check(err)
-
-	//VisitorExpr
-	_, err = fmt.Fprintln(f, "type VisitorExpr interface {")
-	check(err)
-	//body
-	err = VisitorExprBody(f, types)
-	check(err)
-
-	_, err = fmt.Fprintln(f, "}")
-	check(err)
+// DefineAST writes the node types for one base interface (Expr or Stmt) to
+// path: the interface, its visitor, and a struct, constructor and Accept
+// method per node.
+func DefineAST(path string, packageName string, baseName string, types []NodeType) {
+	var f bytes.Buffer
+
+	fmt.Fprintln(&f, "// Code generated by go generate; DO NOT EDIT.")
+	fmt.Fprintln(&f)
+	fmt.Fprintln(&f, "package", packageName)
+	fmt.Fprintln(&f)
+	fmt.Fprintln(&f, `import tokens "go-intepreter/tokens"`)
+	fmt.Fprintln(&f)
+
+	//base interface
+	fmt.Fprintf(&f, "type %s interface {\n", baseName)
+	fmt.Fprintf(&f, "	Accept(visitor Visitor%s) interface{}\n", baseName)
+	fmt.Fprintln(&f, "}")
+	fmt.Fprintln(&f)
+
+	//visitor
+	fmt.Fprintf(&f, "type Visitor%s interface {\n", baseName)
+	VisitorBody(&f, baseName, types)
+	fmt.Fprintln(&f, "}")
 
 	//typedefs and constructors
-	err = TypeConstructs(f, types)
-	check(err)
+	TypeConstructs(&f, baseName, types)
 
+	source, err := format.Source(f.Bytes())
+	check(err)
+	check(os.WriteFile(path, source, 0o644))
 }
 
-func VisitorExprBody(f *os.File, types []ExprType) error {
-	for _, val := range types {
-		// Construct the line using fmt.Sprintf
-		line := fmt.Sprintf(
-			"	Visit%sExpr(expr *%s) interface{}",
-			exprTypeNames[val],
-			strings.TrimSpace(exprTypeNames[val]),
-		)
-
-		// Write the line to the file
-		_, err := fmt.Fprintln(f, line)
-		if err != nil {
-			return err
-		}
+func VisitorBody(f *bytes.Buffer, baseName string, types []NodeType) {
+	for _, t := range types {
+		fmt.Fprintf(f, "	Visit%s%s(%s *%s) interface{}\n", t.Name, baseName, strings.ToLower(baseName), t.Name)
 	}
-
-	return nil
 }
 
-func TypeConstructs(f *os.File, types []ExprType) error {
-	for _, val := range types {
-		_, err := fmt.Fprintln(f, "type", strings.TrimSpace(exprTypeNames[val]), "struct", " {")
-		if err != nil {
-			return err
+func TypeConstructs(f *bytes.Buffer, baseName string, types []NodeType) {
+	for _, t := range types {
+		fields := strings.Split(t.Fields, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
 		}
 
-		splitarr := strings.Split(string(val), ",")
-		for _, s := range splitarr {
-			_, err = fmt.Fprintln(f, "	", strings.TrimSpace(s))
-			if err != nil {
-				return err
-			}
-		}
-		_, err = fmt.Fprintln(f, "}")
-		if err != nil {
-			return err
+		fmt.Fprintln(f)
+		fmt.Fprintf(f, "type %s struct {\n", t.Name)
+		for _, field := range fields {
+			fmt.Fprintln(f, "	"+field)
 		}
-		line := fmt.Sprintf("func New%s(%s) *%s{", exprTypeNames[val], strings.TrimSpace(string(val)), exprTypeNames[val])
-		_, err = fmt.Fprintln(f, line)
-		_, err = fmt.Fprintln(f, "	return &", exprTypeNames[val], "{")
-		if err != nil {
-			return err
+		fmt.Fprintln(f, "}")
+		fmt.Fprintln(f)
+
+		// Parameters are the field names starting lower case, so that a
+		// field named after a node type (Expression) does not shadow it.
+		params := make([]string, len(fields))
+		for i, field := range fields {
+			params[i] = parameterName(field) + " " + strings.Fields(field)[1]
 		}
-		for _, r := range splitarr {
-			sp := strings.Split(strings.TrimSpace(r), " ")
-			_, err = fmt.Fprintln(f, "		", sp[0], ":	", sp[0], ",")
-		}
-		_, err = fmt.Fprintln(f, "	}")
-		_, err = fmt.Fprintln(f, "}")
-		if err != nil {
-			return err
+		fmt.Fprintf(f, "func New%s(%s) *%s {\n", t.Name, strings.Join(params, ", "), t.Name)
+		fmt.Fprintf(f, "	return &%s{\n", t.Name)
+		for _, field := range fields {
+			fmt.Fprintf(f, "		%s: %s,\n", strings.Fields(field)[0], parameterName(field))
 		}
+		fmt.Fprintln(f, "	}")
+		fmt.Fprintln(f, "}")
+		fmt.Fprintln(f)
 
-		line = fmt.Sprintf("func (a *%s) Accept(v VisitorExpr) interface{} {", strings.TrimSpace(exprTypeNames[val]))
-		_, err = fmt.Fprintln(f, line)
-		if err != nil {
-			return err
-		}
-		line = fmt.Sprintf(
-			"	return v.Visit%sExpr(a)",
-			strings.TrimSpace(exprTypeNames[val]),
-		)
-		_, err = fmt.Fprintln(f, line)
-		if err != nil {
-			return err
-		}
-		_, err = fmt.Fprintln(f, "}")
-		if err != nil {
-			return err
-		}
+		fmt.Fprintf(f, "func (a *%s) Accept(v Visitor%s) interface{} {\n", t.Name, baseName)
+		fmt.Fprintf(f, "	return v.Visit%s%s(a)\n", t.Name, baseName)
+		fmt.Fprintln(f, "}")
 	}
+}
 
-	return nil
+func parameterName(field string) string {
+	name := strings.Fields(field)[0]
+	return strings.ToLower(name[:1]) + name[1:]
 }
 
-func GenerateAST() {
+// GenerateAST regenerates the node files in dir.
+func GenerateAST(dir string) {
 	println("Generating AST")
-	types := []ExprType{BINARY, UNARY, GROUPING, LITERAL}
-	DefineAST("./gen/generated.go", "gen", types)
+	DefineAST(filepath.Join(dir, "generated.go"), "gen", "Expr", ExprTypes)
+	DefineAST(filepath.Join(dir, "generated_stmt.go"), "gen", "Stmt", StmtTypes)
+	DefineAST(filepath.Join(dir, "generated_pattern.go"), "gen", "Pattern", PatternTypes)
 }
@@ -0,0 +1,9 @@
+// Command genast regenerates the AST node files of package gen. It is run by
+// go generate from the gen directory.
+package main
+
+import "go-intepreter/gen"
+
+func main() {
+	gen.GenerateAST(".")
+}
@@ -1,36 +1,54 @@
-package gen
-
-
+// Code generated by go generate; DO NOT EDIT.
 
-import (
-	"strconv"
+package gen
 
-	tokens "go-intepreter/tokens"
-)
+import tokens "go-intepreter/tokens"
 
 type Expr interface {
-	Accept(visitor VisitorExpr)interface{} 	
+	Accept(visitor VisitorExpr) interface{}
 }
+
 type VisitorExpr interface {
-	VisitBinaryExpr(expr *Binary) interface{} 
-	VisitUnaryExpr(expr *Unary) interface{} 
-	VisitGroupingExpr(expr *Grouping) interface{} 
-	VisitLiteralExpr(expr *Literal) interface{} 
+	VisitBinaryExpr(expr *Binary) interface{}
+	VisitUnaryExpr(expr *Unary) interface{}
+	VisitGroupingExpr(expr *Grouping) interface{}
+	VisitLiteralExpr(expr *Literal) interface{}
+	VisitInterpolationExpr(expr *Interpolation) interface{}
+	VisitVariableExpr(expr *Variable) interface{}
+	VisitAssignExpr(expr *Assign) interface{}
+	VisitLogicalExpr(expr *Logical) interface{}
+	VisitCallExpr(expr *Call) interface{}
+	VisitGetExpr(expr *Get) interface{}
+	VisitSetExpr(expr *Set) interface{}
+	VisitThisExpr(expr *This) interface{}
+	VisitSuperExpr(expr *Super) interface{}
+	VisitConditionalExpr(expr *Conditional) interface{}
+	VisitCommaExpr(expr *Comma) interface{}
+	VisitCompoundExpr(expr *Compound) interface{}
+	VisitIncrementExpr(expr *Increment) interface{}
+	VisitListExpr(expr *List) interface{}
+	VisitMapExpr(expr *Map) interface{}
+	VisitIndexExpr(expr *Index) interface{}
+	VisitSliceExpr(expr *Slice) interface{}
+	VisitSetIndexExpr(expr *SetIndex) interface{}
+	VisitMatchExpr(expr *Match) interface{}
 }
+
 type Binary struct {
 	Left     Expr
 	Right    Expr
 	Operator *tokens.Token
 }
 
-func NewBinary(Left Expr, Right Expr, Operator *tokens.Token) *Binary {
+func NewBinary(left Expr, right Expr, operator *tokens.Token) *Binary {
 	return &Binary{
-		Left:     Left,
-		Right:    Right,
-		Operator: Operator,
+		Left:     left,
+		Right:    right,
+		Operator: operator,
 	}
 }
-func (a *Binary) Accept(v VisitorExpr) interface{}  {
+
+func (a *Binary) Accept(v VisitorExpr) interface{} {
 	return v.VisitBinaryExpr(a)
 }
 
@@ -39,13 +57,14 @@ type Unary struct {
 	Right    Expr
 }
 
-func NewUnary(Operator *tokens.Token, Right Expr) *Unary {
+func NewUnary(operator *tokens.Token, right Expr) *Unary {
 	return &Unary{
-		Operator: Operator,
-		Right:    Right,
+		Operator: operator,
+		Right:    right,
 	}
 }
-func (a *Unary) Accept(v VisitorExpr) interface{}  {
+
+func (a *Unary) Accept(v VisitorExpr) interface{} {
 	return v.VisitUnaryExpr(a)
 }
 
@@ -53,29 +72,352 @@ type Grouping struct {
 	Expression Expr
 }
 
-func NewGrouping(Expression Expr) *Grouping {
+func NewGrouping(expression Expr) *Grouping {
 	return &Grouping{
-		Expression: Expression,
+		Expression: expression,
 	}
 }
-func (a *Grouping) Accept(v VisitorExpr) interface{}  {
+
+func (a *Grouping) Accept(v VisitorExpr) interface{} {
 	return v.VisitGroupingExpr(a)
 }
 
 type Literal struct {
 	Value interface{}
+	Token *tokens.Token
 }
 
-func NewLiteral(Value interface{}) *Literal {
-    // Convert numeric literals to float64 explicitly
-    if v, ok := Value.(string); ok {
-        if num, err := strconv.ParseFloat(v, 64); err == nil {
-            return &Literal{Value: num}
-        }
-    }
-    return &Literal{Value: Value}
+func NewLiteral(value interface{}, token *tokens.Token) *Literal {
+	return &Literal{
+		Value: value,
+		Token: token,
+	}
 }
 
-func (a *Literal) Accept(v VisitorExpr) interface{}  {
+func (a *Literal) Accept(v VisitorExpr) interface{} {
 	return v.VisitLiteralExpr(a)
 }
+
+type Interpolation struct {
+	Parts []Expr
+}
+
+func NewInterpolation(parts []Expr) *Interpolation {
+	return &Interpolation{
+		Parts: parts,
+	}
+}
+
+func (a *Interpolation) Accept(v VisitorExpr) interface{} {
+	return v.VisitInterpolationExpr(a)
+}
+
+type Variable struct {
+	Name *tokens.Token
+}
+
+func NewVariable(name *tokens.Token) *Variable {
+	return &Variable{
+		Name: name,
+	}
+}
+
+func (a *Variable) Accept(v VisitorExpr) interface{} {
+	return v.VisitVariableExpr(a)
+}
+
+type Assign struct {
+	Name  *tokens.Token
+	Value Expr
+}
+
+func NewAssign(name *tokens.Token, value Expr) *Assign {
+	return &Assign{
+		Name:  name,
+		Value: value,
+	}
+}
+
+func (a *Assign) Accept(v VisitorExpr) interface{} {
+	return v.VisitAssignExpr(a)
+}
+
+type Logical struct {
+	Left     Expr
+	Right    Expr
+	Operator *tokens.Token
+}
+
+func NewLogical(left Expr, right Expr, operator *tokens.Token) *Logical {
+	return &Logical{
+		Left:     left,
+		Right:    right,
+		Operator: operator,
+	}
+}
+
+func (a *Logical) Accept(v VisitorExpr) interface{} {
+	return v.VisitLogicalExpr(a)
+}
+
+type Call struct {
+	Callee    Expr
+	Paren     *tokens.Token
+	Arguments []Expr
+}
+
+func NewCall(callee Expr, paren *tokens.Token, arguments []Expr) *Call {
+	return &Call{
+		Callee:    callee,
+		Paren:     paren,
+		Arguments: arguments,
+	}
+}
+
+func (a *Call) Accept(v VisitorExpr) interface{} {
+	return v.VisitCallExpr(a)
+}
+
+type Get struct {
+	Object Expr
+	Name   *tokens.Token
+}
+
+func NewGet(object Expr, name *tokens.Token) *Get {
+	return &Get{
+		Object: object,
+		Name:   name,
+	}
+}
+
+func (a *Get) Accept(v VisitorExpr) interface{} {
+	return v.VisitGetExpr(a)
+}
+
+type Set struct {
+	Object Expr
+	Name   *tokens.Token
+	Value  Expr
+}
+
+func NewSet(object Expr, name *tokens.Token, value Expr) *Set {
+	return &Set{
+		Object: object,
+		Name:   name,
+		Value:  value,
+	}
+}
+
+func (a *Set) Accept(v VisitorExpr) interface{} {
+	return v.VisitSetExpr(a)
+}
+
+type This struct {
+	Keyword *tokens.Token
+}
+
+func NewThis(keyword *tokens.Token) *This {
+	return &This{
+		Keyword: keyword,
+	}
+}
+
+func (a *This) Accept(v VisitorExpr) interface{} {
+	return v.VisitThisExpr(a)
+}
+
+type Super struct {
+	Keyword *tokens.Token
+	Method  *tokens.Token
+}
+
+func NewSuper(keyword *tokens.Token, method *tokens.Token) *Super {
+	return &Super{
+		Keyword: keyword,
+		Method:  method,
+	}
+}
+
+func (a *Super) Accept(v VisitorExpr) interface{} {
+	return v.VisitSuperExpr(a)
+}
+
+type Conditional struct {
+	Condition  Expr
+	ThenBranch Expr
+	ElseBranch Expr
+}
+
+func NewConditional(condition Expr, thenBranch Expr, elseBranch Expr) *Conditional {
+	return &Conditional{
+		Condition:  condition,
+		ThenBranch: thenBranch,
+		ElseBranch: elseBranch,
+	}
+}
+
+func (a *Conditional) Accept(v VisitorExpr) interface{} {
+	return v.VisitConditionalExpr(a)
+}
+
+type Comma struct {
+	Left  Expr
+	Right Expr
+}
+
+func NewComma(left Expr, right Expr) *Comma {
+	return &Comma{
+		Left:  left,
+		Right: right,
+	}
+}
+
+func (a *Comma) Accept(v VisitorExpr) interface{} {
+	return v.VisitCommaExpr(a)
+}
+
+type Compound struct {
+	Target   Expr
+	Operator *tokens.Token
+	Value    Expr
+}
+
+func NewCompound(target Expr, operator *tokens.Token, value Expr) *Compound {
+	return &Compound{
+		Target:   target,
+		Operator: operator,
+		Value:    value,
+	}
+}
+
+func (a *Compound) Accept(v VisitorExpr) interface{} {
+	return v.VisitCompoundExpr(a)
+}
+
+type Increment struct {
+	Target   Expr
+	Operator *tokens.Token
+	Prefix   bool
+}
+
+func NewIncrement(target Expr, operator *tokens.Token, prefix bool) *Increment {
+	return &Increment{
+		Target:   target,
+		Operator: operator,
+		Prefix:   prefix,
+	}
+}
+
+func (a *Increment) Accept(v VisitorExpr) interface{} {
+	return v.VisitIncrementExpr(a)
+}
+
+type List struct {
+	Bracket  *tokens.Token
+	Elements []Expr
+}
+
+func NewList(bracket *tokens.Token, elements []Expr) *List {
+	return &List{
+		Bracket:  bracket,
+		Elements: elements,
+	}
+}
+
+func (a *List) Accept(v VisitorExpr) interface{} {
+	return v.VisitListExpr(a)
+}
+
+type Map struct {
+	Brace  *tokens.Token
+	Keys   []Expr
+	Values []Expr
+}
+
+func NewMap(brace *tokens.Token, keys []Expr, values []Expr) *Map {
+	return &Map{
+		Brace:  brace,
+		Keys:   keys,
+		Values: values,
+	}
+}
+
+func (a *Map) Accept(v VisitorExpr) interface{} {
+	return v.VisitMapExpr(a)
+}
+
+type Index struct {
+	Object  Expr
+	Bracket *tokens.Token
+	Index   Expr
+}
+
+func NewIndex(object Expr, bracket *tokens.Token, index Expr) *Index {
+	return &Index{
+		Object:  object,
+		Bracket: bracket,
+		Index:   index,
+	}
+}
+
+func (a *Index) Accept(v VisitorExpr) interface{} {
+	return v.VisitIndexExpr(a)
+}
+
+type Slice struct {
+	Object  Expr
+	Bracket *tokens.Token
+	Start   Expr
+	End     Expr
+}
+
+func NewSlice(object Expr, bracket *tokens.Token, start Expr, end Expr) *Slice {
+	return &Slice{
+		Object:  object,
+		Bracket: bracket,
+		Start:   start,
+		End:     end,
+	}
+}
+
+func (a *Slice) Accept(v VisitorExpr) interface{} {
+	return v.VisitSliceExpr(a)
+}
+
+type SetIndex struct {
+	Object  Expr
+	Bracket *tokens.Token
+	Index   Expr
+	Value   Expr
+}
+
+func NewSetIndex(object Expr, bracket *tokens.Token, index Expr, value Expr) *SetIndex {
+	return &SetIndex{
+		Object:  object,
+		Bracket: bracket,
+		Index:   index,
+		Value:   value,
+	}
+}
+
+func (a *SetIndex) Accept(v VisitorExpr) interface{} {
+	return v.VisitSetIndexExpr(a)
+}
+
+type Match struct {
+	Keyword *tokens.Token
+	Subject Expr
+	Arms    []*MatchArm
+}
+
+func NewMatch(keyword *tokens.Token, subject Expr, arms []*MatchArm) *Match {
+	return &Match{
+		Keyword: keyword,
+		Subject: subject,
+		Arms:    arms,
+	}
+}
+
+func (a *Match) Accept(v VisitorExpr) interface{} {
+	return v.VisitMatchExpr(a)
+}
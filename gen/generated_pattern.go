@@ -0,0 +1,139 @@
+// Code generated by go generate; DO NOT EDIT.
+
+package gen
+
+import tokens "go-intepreter/tokens"
+
+type Pattern interface {
+	Accept(visitor VisitorPattern) interface{}
+}
+
+type VisitorPattern interface {
+	VisitConstantPattern(pattern *Constant) interface{}
+	VisitRangePattern(pattern *Range) interface{}
+	VisitTypeTestPattern(pattern *TypeTest) interface{}
+	VisitBindingPattern(pattern *Binding) interface{}
+	VisitWildcardPattern(pattern *Wildcard) interface{}
+	VisitListDestructurePattern(pattern *ListDestructure) interface{}
+	VisitMapDestructurePattern(pattern *MapDestructure) interface{}
+}
+
+type Constant struct {
+	Value interface{}
+	Token *tokens.Token
+}
+
+func NewConstant(value interface{}, token *tokens.Token) *Constant {
+	return &Constant{
+		Value: value,
+		Token: token,
+	}
+}
+
+func (a *Constant) Accept(v VisitorPattern) interface{} {
+	return v.VisitConstantPattern(a)
+}
+
+type Range struct {
+	Low       interface{}
+	High      interface{}
+	Inclusive bool
+	Operator  *tokens.Token
+}
+
+func NewRange(low interface{}, high interface{}, inclusive bool, operator *tokens.Token) *Range {
+	return &Range{
+		Low:       low,
+		High:      high,
+		Inclusive: inclusive,
+		Operator:  operator,
+	}
+}
+
+func (a *Range) Accept(v VisitorPattern) interface{} {
+	return v.VisitRangePattern(a)
+}
+
+type TypeTest struct {
+	Name     *tokens.Token
+	TypeName *tokens.Token
+	Class    Expr
+}
+
+func NewTypeTest(name *tokens.Token, typeName *tokens.Token, class Expr) *TypeTest {
+	return &TypeTest{
+		Name:     name,
+		TypeName: typeName,
+		Class:    class,
+	}
+}
+
+func (a *TypeTest) Accept(v VisitorPattern) interface{} {
+	return v.VisitTypeTestPattern(a)
+}
+
+type Binding struct {
+	Name *tokens.Token
+}
+
+func NewBinding(name *tokens.Token) *Binding {
+	return &Binding{
+		Name: name,
+	}
+}
+
+func (a *Binding) Accept(v VisitorPattern) interface{} {
+	return v.VisitBindingPattern(a)
+}
+
+type Wildcard struct {
+	Token *tokens.Token
+}
+
+func NewWildcard(token *tokens.Token) *Wildcard {
+	return &Wildcard{
+		Token: token,
+	}
+}
+
+func (a *Wildcard) Accept(v VisitorPattern) interface{} {
+	return v.VisitWildcardPattern(a)
+}
+
+type ListDestructure struct {
+	Bracket *tokens.Token
+	Before  []Pattern
+	Rest    *tokens.Token
+	After   []Pattern
+}
+
+func NewListDestructure(bracket *tokens.Token, before []Pattern, rest *tokens.Token, after []Pattern) *ListDestructure {
+	return &ListDestructure{
+		Bracket: bracket,
+		Before:  before,
+		Rest:    rest,
+		After:   after,
+	}
+}
+
+func (a *ListDestructure) Accept(v VisitorPattern) interface{} {
+	return v.VisitListDestructurePattern(a)
+}
+
+type MapDestructure struct {
+	Brace  *tokens.Token
+	Keys   []Expr
+	Values []Pattern
+}
+
+func NewMapDestructure(brace *tokens.Token, keys []Expr, values []Pattern) *MapDestructure {
+	return &MapDestructure{
+		Brace:  brace,
+		Keys:   keys,
+		Values: values,
+	}
+}
+
+func (a *MapDestructure) Accept(v VisitorPattern) interface{} {
+	return v.VisitMapDestructurePattern(a)
+}
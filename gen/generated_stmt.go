@@ -0,0 +1,205 @@
+// Code generated by go generate; DO NOT EDIT.
+
+package gen
+
+import tokens "go-intepreter/tokens"
+
+type Stmt interface {
+	Accept(visitor VisitorStmt) interface{}
+}
+
+type VisitorStmt interface {
+	VisitExpressionStmt(stmt *Expression) interface{}
+	VisitPrintStmt(stmt *Print) interface{}
+	VisitVarStmt(stmt *Var) interface{}
+	VisitBlockStmt(stmt *Block) interface{}
+	VisitIfStmt(stmt *If) interface{}
+	VisitWhileStmt(stmt *While) interface{}
+	VisitFunctionStmt(stmt *Function) interface{}
+	VisitReturnStmt(stmt *Return) interface{}
+	VisitClassStmt(stmt *Class) interface{}
+	VisitThrowStmt(stmt *Throw) interface{}
+	VisitTryStmt(stmt *Try) interface{}
+}
+
+type Expression struct {
+	Expression Expr
+}
+
+func NewExpression(expression Expr) *Expression {
+	return &Expression{
+		Expression: expression,
+	}
+}
+
+func (a *Expression) Accept(v VisitorStmt) interface{} {
+	return v.VisitExpressionStmt(a)
+}
+
+type Print struct {
+	Expression Expr
+}
+
+func NewPrint(expression Expr) *Print {
+	return &Print{
+		Expression: expression,
+	}
+}
+
+func (a *Print) Accept(v VisitorStmt) interface{} {
+	return v.VisitPrintStmt(a)
+}
+
+type Var struct {
+	Name        *tokens.Token
+	Initializer Expr
+}
+
+func NewVar(name *tokens.Token, initializer Expr) *Var {
+	return &Var{
+		Name:        name,
+		Initializer: initializer,
+	}
+}
+
+func (a *Var) Accept(v VisitorStmt) interface{} {
+	return v.VisitVarStmt(a)
+}
+
+type Block struct {
+	Statements []Stmt
+}
+
+func NewBlock(statements []Stmt) *Block {
+	return &Block{
+		Statements: statements,
+	}
+}
+
+func (a *Block) Accept(v VisitorStmt) interface{} {
+	return v.VisitBlockStmt(a)
+}
+
+type If struct {
+	Condition  Expr
+	ThenBranch Stmt
+	ElseBranch Stmt
+}
+
+func NewIf(condition Expr, thenBranch Stmt, elseBranch Stmt) *If {
+	return &If{
+		Condition:  condition,
+		ThenBranch: thenBranch,
+		ElseBranch: elseBranch,
+	}
+}
+
+func (a *If) Accept(v VisitorStmt) interface{} {
+	return v.VisitIfStmt(a)
+}
+
+type While struct {
+	Condition Expr
+	Body      Stmt
+}
+
+func NewWhile(condition Expr, body Stmt) *While {
+	return &While{
+		Condition: condition,
+		Body:      body,
+	}
+}
+
+func (a *While) Accept(v VisitorStmt) interface{} {
+	return v.VisitWhileStmt(a)
+}
+
+type Function struct {
+	Name   *tokens.Token
+	Params []*tokens.Token
+	Body   []Stmt
+}
+
+func NewFunction(name *tokens.Token, params []*tokens.Token, body []Stmt) *Function {
+	return &Function{
+		Name:   name,
+		Params: params,
+		Body:   body,
+	}
+}
+
+func (a *Function) Accept(v VisitorStmt) interface{} {
+	return v.VisitFunctionStmt(a)
+}
+
+type Return struct {
+	Keyword *tokens.Token
+	Value   Expr
+}
+
+func NewReturn(keyword *tokens.Token, value Expr) *Return {
+	return &Return{
+		Keyword: keyword,
+		Value:   value,
+	}
+}
+
+func (a *Return) Accept(v VisitorStmt) interface{} {
+	return v.VisitReturnStmt(a)
+}
+
+type Class struct {
+	Name       *tokens.Token
+	Superclass *Variable
+	Methods    []*Function
+}
+
+func NewClass(name *tokens.Token, superclass *Variable, methods []*Function) *Class {
+	return &Class{
+		Name:       name,
+		Superclass: superclass,
+		Methods:    methods,
+	}
+}
+
+func (a *Class) Accept(v VisitorStmt) interface{} {
+	return v.VisitClassStmt(a)
+}
+
+type Throw struct {
+	Keyword *tokens.Token
+	Value   Expr
+}
+
+func NewThrow(keyword *tokens.Token, value Expr) *Throw {
+	return &Throw{
+		Keyword: keyword,
+		Value:   value,
+	}
+}
+
+func (a *Throw) Accept(v VisitorStmt) interface{} {
+	return v.VisitThrowStmt(a)
+}
+
+type Try struct {
+	Keyword     *tokens.Token
+	Body        []Stmt
+	CatchName   *tokens.Token
+	CatchBody   []Stmt
+	FinallyBody []Stmt
+}
+
+func NewTry(keyword *tokens.Token, body []Stmt, catchName *tokens.Token, catchBody []Stmt, finallyBody []Stmt) *Try {
+	return &Try{
+		Keyword:     keyword,
+		Body:        body,
+		CatchName:   catchName,
+		CatchBody:   catchBody,
+		FinallyBody: finallyBody,
+	}
+}
+
+func (a *Try) Accept(v VisitorStmt) interface{} {
+	return v.VisitTryStmt(a)
+}
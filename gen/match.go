@@ -0,0 +1,9 @@
+package gen
+
+// MatchArm is one arm of a match expression: pattern if guard => body. Guard
+// is nil when the arm has none.
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expr
+	Body    Expr
+}
@@ -0,0 +1,72 @@
+package gen
+
+import tokens "go-intepreter/tokens"
+
+// SpanOf returns the region of the source an expression was parsed from.
+func SpanOf(expr Expr) tokens.Span {
+	switch e := expr.(type) {
+	case *Binary:
+		return SpanOf(e.Left).To(SpanOf(e.Right))
+	case *Logical:
+		return SpanOf(e.Left).To(SpanOf(e.Right))
+	case *Conditional:
+		return SpanOf(e.Condition).To(SpanOf(e.ElseBranch))
+	case *Comma:
+		return SpanOf(e.Left).To(SpanOf(e.Right))
+	case *Compound:
+		return SpanOf(e.Target).To(SpanOf(e.Value))
+	case *Increment:
+		if e.Prefix {
+			return e.Operator.Span().To(SpanOf(e.Target))
+		}
+		return SpanOf(e.Target).To(e.Operator.Span())
+	case *List:
+		if len(e.Elements) > 0 {
+			return e.Bracket.Span().To(SpanOf(e.Elements[len(e.Elements)-1]))
+		}
+		return e.Bracket.Span()
+	case *Map:
+		if len(e.Values) > 0 {
+			return e.Brace.Span().To(SpanOf(e.Values[len(e.Values)-1]))
+		}
+		return e.Brace.Span()
+	case *Match:
+		if len(e.Arms) > 0 {
+			return e.Keyword.Span().To(SpanOf(e.Arms[len(e.Arms)-1].Body))
+		}
+		return e.Keyword.Span()
+	case *Index:
+		return SpanOf(e.Object).To(e.Bracket.Span())
+	case *Slice:
+		return SpanOf(e.Object).To(e.Bracket.Span())
+	case *SetIndex:
+		return SpanOf(e.Object).To(SpanOf(e.Value))
+	case *Unary:
+		return e.Operator.Span().To(SpanOf(e.Right))
+	case *Grouping:
+		return SpanOf(e.Expression)
+	case *Interpolation:
+		if len(e.Parts) > 0 {
+			return SpanOf(e.Parts[0]).To(SpanOf(e.Parts[len(e.Parts)-1]))
+		}
+	case *Call:
+		return SpanOf(e.Callee).To(e.Paren.Span())
+	case *Get:
+		return SpanOf(e.Object).To(e.Name.Span())
+	case *Set:
+		return SpanOf(e.Object).To(SpanOf(e.Value))
+	case *This:
+		return e.Keyword.Span()
+	case *Super:
+		return e.Keyword.Span().To(e.Method.Span())
+	case *Variable:
+		return e.Name.Span()
+	case *Assign:
+		return e.Name.Span().To(SpanOf(e.Value))
+	case *Literal:
+		if e.Token != nil {
+			return e.Token.Span()
+		}
+	}
+	return tokens.Span{}
+}
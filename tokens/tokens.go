@@ -1,6 +1,10 @@
 package tokens
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"strings"
+)
 
 type TokenType string
 
@@ -17,6 +21,12 @@ const (
 	SEMICOLON   TokenType = "SEMICOLON"
 	SLASH       TokenType = "SLASH"
 	STAR        TokenType = "STAR"
+	PERCENT     TokenType = "PERCENT"
+	QUESTION    TokenType = "QUESTION"
+	COLON       TokenType = "COLON"
+	// Brackets, for lists and indexing.
+	LEFT_BRACKET  TokenType = "LEFT_BRACKET"
+	RIGHT_BRACKET TokenType = "RIGHT_BRACKET"
 
 	// One or two character tokens.
 	BANG          TokenType = "BANG"
@@ -27,11 +37,25 @@ const (
 	GREATER_EQUAL TokenType = "GREATER_EQUAL"
 	LESS          TokenType = "LESS"
 	LESS_EQUAL    TokenType = "LESS_EQUAL"
+	PLUS_EQUAL    TokenType = "PLUS_EQUAL"
+	MINUS_EQUAL   TokenType = "MINUS_EQUAL"
+	STAR_EQUAL    TokenType = "STAR_EQUAL"
+	SLASH_EQUAL   TokenType = "SLASH_EQUAL"
+	PERCENT_EQUAL TokenType = "PERCENT_EQUAL"
+	PLUS_PLUS     TokenType = "PLUS_PLUS"
+	MINUS_MINUS   TokenType = "MINUS_MINUS"
+	ARROW         TokenType = "ARROW"
+	DOT_DOT       TokenType = "DOT_DOT"
+	DOT_DOT_EQUAL TokenType = "DOT_DOT_EQUAL"
 
 	// Literals.
 	IDENTIFIER TokenType = "IDENTIFIER"
 	STRING     TokenType = "STRING"
 	NUMBER     TokenType = "NUMBER"
+	// INTERPOLATION is a piece of string literal that ends at "${". The
+	// interpolated expression's tokens follow it, and the literal carries on
+	// with another INTERPOLATION or a closing STRING.
+	INTERPOLATION TokenType = "INTERPOLATION"
 
 	// Keywords.
 	AND    TokenType = "AND"
@@ -41,6 +65,8 @@ const (
 	FUN    TokenType = "FUN"
 	FOR    TokenType = "FOR"
 	IF     TokenType = "IF"
+	IS     TokenType = "IS"
+	MATCH  TokenType = "MATCH"
 	NIL    TokenType = "NIL"
 	OR     TokenType = "OR"
 	PRINT  TokenType = "PRINT"
@@ -51,6 +77,12 @@ const (
 	VAR    TokenType = "VAR"
 	WHILE  TokenType = "WHILE"
 	EOF    TokenType = "EOF"
+
+	// Keywords of try/catch/finally and throw.
+	CATCH   TokenType = "CATCH"
+	FINALLY TokenType = "FINALLY"
+	THROW   TokenType = "THROW"
+	TRY     TokenType = "TRY"
 )
 
 type Token struct {
@@ -58,6 +90,52 @@ type Token struct {
 	Lexeme  string
 	Literal interface{}
 	Line    int
+
+	// Column is the 1-based column of the first character of the token on Line.
+	Column int
+	// Start and End are the byte offsets of the token in the source, End exclusive.
+	Start int
+	End   int
+
+	// LeadingTrivia and TrailingTrivia hold the whitespace and comments around
+	// the token. They are only filled in when the scanner is asked to keep
+	// trivia. Trailing trivia runs up to the end of the token's line; anything
+	// after that leads the next token.
+	LeadingTrivia  []Trivia
+	TrailingTrivia []Trivia
+}
+
+type TriviaKind string
+
+const (
+	WHITESPACE    TriviaKind = "WHITESPACE"
+	NEWLINE       TriviaKind = "NEWLINE"
+	LINE_COMMENT  TriviaKind = "LINE_COMMENT"
+	BLOCK_COMMENT TriviaKind = "BLOCK_COMMENT"
+	// SKIPPED is source the scanner reported an error for and made no token from.
+	SKIPPED TriviaKind = "SKIPPED"
+)
+
+// Trivia is a piece of source text that is not part of any token.
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+}
+
+// Reconstruct returns the source a token stream was scanned from. It is
+// byte-for-byte identical to the original when the tokens carry trivia.
+func Reconstruct(tokens []*Token) string {
+	var builder strings.Builder
+	for _, t := range tokens {
+		for _, trivia := range t.LeadingTrivia {
+			builder.WriteString(trivia.Text)
+		}
+		builder.WriteString(t.Lexeme)
+		for _, trivia := range t.TrailingTrivia {
+			builder.WriteString(trivia.Text)
+		}
+	}
+	return builder.String()
 }
 
 func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int) Token {
@@ -69,11 +147,48 @@ func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int)
 	}
 }
 
+// Span returns the region of the source covered by the token.
+func (t *Token) Span() Span {
+	return Span{
+		Start:  t.Start,
+		End:    t.End,
+		Line:   t.Line,
+		Column: t.Column,
+	}
+}
+
+// Span is a half-open byte range [Start, End) of the source together with
+// the line and column where it begins.
+type Span struct {
+	Start  int
+	End    int
+	Line   int
+	Column int
+}
+
+// To returns a span that starts where s starts and ends where other ends.
+func (s Span) To(other Span) Span {
+	if other.End > s.End {
+		s.End = other.End
+	}
+	return s
+}
+
 func (t Token) String() string {
 	var literal string
-	if t.Literal == nil {
+	switch v := t.Literal.(type) {
+	case nil:
 		literal = "null"
-	} else {
+	case int64:
+		literal = fmt.Sprintf("%d", v)
+	case float64:
+		// Whole numbers keep one decimal place so they read as numbers.
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			literal = fmt.Sprintf("%.1f", v)
+		} else {
+			literal = fmt.Sprintf("%v", v)
+		}
+	default:
 		literal = fmt.Sprintf("%v", t.Literal)
 	}
 	return fmt.Sprintf("%s %s %s", t.Type, t.Lexeme, literal)
@@ -81,23 +196,33 @@ func (t Token) String() string {
 
 var Keywords map[string]TokenType
 
+func init() {
+	CreateKeyWords()
+}
+
 func CreateKeyWords() {
 	Keywords = make(map[string]TokenType)
 
 	Keywords["and"] = AND
+	Keywords["catch"] = CATCH
 	Keywords["class"] = CLASS
 	Keywords["else"] = ELSE
 	Keywords["false"] = FALSE
+	Keywords["finally"] = FINALLY
 	Keywords["fun"] = FUN
 	Keywords["for"] = FOR
 	Keywords["if"] = IF
+	Keywords["is"] = IS
+	Keywords["match"] = MATCH
 	Keywords["nil"] = NIL
 	Keywords["or"] = OR
 	Keywords["print"] = PRINT
 	Keywords["return"] = RETURN
 	Keywords["super"] = SUPER
 	Keywords["this"] = THIS
+	Keywords["throw"] = THROW
 	Keywords["true"] = TRUE
+	Keywords["try"] = TRY
 	Keywords["var"] = VAR
 	Keywords["while"] = WHILE
 }